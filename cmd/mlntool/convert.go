@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	. "github.com/marisvali/vlok/world"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{"frame", "x", "y", "pick", "release", "move_left",
+	"move_right", "move_up", "move_down", "move_to_food"}
+
+func inputBools(input *PlayerInput) []*bool {
+	return []*bool{&input.Pick, &input.Release, &input.MoveLeft,
+		&input.MoveRight, &input.MoveUp, &input.MoveDown, &input.MoveToFood}
+}
+
+// formatInput returns the position and the names of the bools that are set,
+// like "(100, 200) pick move_left".
+func formatInput(input PlayerInput) string {
+	s := fmt.Sprintf("(%d, %d)", input.Position.X.ToInt64(),
+		input.Position.Y.ToInt64())
+	for i, b := range inputBools(&input) {
+		if *b {
+			s += " " + csvHeader[3+i]
+		}
+	}
+	return s
+}
+
+func inputsToCsv(inputs []PlayerInput) []byte {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	Check(w.Write(csvHeader))
+	for i := range inputs {
+		record := []string{
+			strconv.Itoa(i),
+			strconv.FormatInt(inputs[i].Position.X.ToInt64(), 10),
+			strconv.FormatInt(inputs[i].Position.Y.ToInt64(), 10),
+		}
+		for _, b := range inputBools(&inputs[i]) {
+			if *b {
+				record = append(record, "1")
+			} else {
+				record = append(record, "0")
+			}
+		}
+		Check(w.Write(record))
+	}
+	w.Flush()
+	Check(w.Error())
+	return buf.Bytes()
+}
+
+// inputsFromCsv reads the inputs written by inputsToCsv. The frame column
+// must count up from 0, so that no frame is missing.
+func inputsFromCsv(data []byte) (inputs []PlayerInput) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	Check(err)
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+		Check(fmt.Errorf("expected CSV header: %s", strings.Join(csvHeader, ",")))
+	}
+
+	for line, record := range records[1:] {
+		var values []int64
+		for _, field := range record {
+			v, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				Check(fmt.Errorf("line %d: %w", line+2, err))
+			}
+			values = append(values, v)
+		}
+		if values[0] != int64(len(inputs)) {
+			Check(fmt.Errorf("line %d: expected frame %d, got %d", line+2,
+				len(inputs), values[0]))
+		}
+
+		var input PlayerInput
+		input.Position = Pt{I64(values[1]), I64(values[2])}
+		for i, b := range inputBools(&input) {
+			*b = values[3+i] != 0
+		}
+		inputs = append(inputs, input)
+	}
+	return
+}
+
+type jsonInput struct {
+	X          int64 `json:"x"`
+	Y          int64 `json:"y"`
+	Pick       bool  `json:"pick,omitempty"`
+	Release    bool  `json:"release,omitempty"`
+	MoveLeft   bool  `json:"move_left,omitempty"`
+	MoveRight  bool  `json:"move_right,omitempty"`
+	MoveUp     bool  `json:"move_up,omitempty"`
+	MoveDown   bool  `json:"move_down,omitempty"`
+	MoveToFood bool  `json:"move_to_food,omitempty"`
+}
+
+type jsonPlaythrough struct {
+	Version int64       `json:"version"`
+	Seed    int64       `json:"seed"`
+	History []jsonInput `json:"history"`
+}
+
+func playthroughToJson(p Playthrough) []byte {
+	j := jsonPlaythrough{p.Version, p.Seed.ToInt64(), []jsonInput{}}
+	for _, input := range p.History {
+		j.History = append(j.History, jsonInput{
+			input.Position.X.ToInt64(), input.Position.Y.ToInt64(),
+			input.Pick, input.Release, input.MoveLeft, input.MoveRight,
+			input.MoveUp, input.MoveDown, input.MoveToFood})
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	Check(err)
+	return data
+}
+
+func playthroughFromJson(data []byte) (p Playthrough) {
+	var j jsonPlaythrough
+	Check(json.Unmarshal(data, &j))
+	p.Version = j.Version
+	p.Seed = I64(j.Seed)
+	for _, i := range j.History {
+		p.History = append(p.History, PlayerInput{
+			Pt{I64(i.X), I64(i.Y)},
+			i.Pick, i.Release, i.MoveLeft, i.MoveRight,
+			i.MoveUp, i.MoveDown, i.MoveToFood})
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	. "github.com/marisvali/vlok/world"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testPlaythrough() (p Playthrough) {
+	p.Version = 1
+	p.Seed = I(77)
+	p.History = []PlayerInput{
+		{Position: IPt(100, 200)},
+		{Position: IPt(100, 200), Pick: true},
+		{Position: IPt(-5, 0), MoveLeft: true, MoveToFood: true},
+	}
+	return
+}
+
+func TestCsv(t *testing.T) {
+	p := testPlaythrough()
+	data := inputsToCsv(p.History)
+	assert.Equal(t, "frame,x,y,pick,release,move_left,move_right,move_up,move_down,move_to_food\n"+
+		"0,100,200,0,0,0,0,0,0,0\n"+
+		"1,100,200,1,0,0,0,0,0,0\n"+
+		"2,-5,0,0,0,1,0,0,0,1\n", string(data))
+	assert.Equal(t, p.History, inputsFromCsv(data))
+
+	assert.Panics(t, func() { inputsFromCsv([]byte("x,y\n1,2\n")) })
+	assert.Panics(t, func() {
+		inputsFromCsv([]byte(string(data[:len(data)-1]) + "\n5,0,0,0,0,0,0,0,0,0\n"))
+	})
+}
+
+func TestJson(t *testing.T) {
+	p := testPlaythrough()
+	assert.Equal(t, p, playthroughFromJson(playthroughToJson(p)))
+}
+
+func TestFormatInput(t *testing.T) {
+	assert.Equal(t, "(-5, 0) move_left move_to_food",
+		formatInput(testPlaythrough().History[2]))
+}
+
+func TestFrameRange(t *testing.T) {
+	h := testPlaythrough().History
+	assert.Equal(t, h[1:], frameRange(h, 1, 0))
+	assert.Equal(t, h[:2], frameRange(h, 0, 2))
+	assert.Equal(t, h, frameRange(h, 0, 100))
+	assert.Panics(t, func() { frameRange(h, 2, 1) })
+}
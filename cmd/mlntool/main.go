@@ -0,0 +1,340 @@
+// Command mlntool inspects and converts recordings (.mln files).
+//
+// Usage:
+//
+//	mlntool info <recording>
+//	mlntool dump [-from N] [-to N] <recording>
+//	mlntool export <recording> <output.csv|output.json>
+//	mlntool import [-seed N] [-version N] <input.csv|input.json> <recording>
+//	mlntool extract -from N -to N <recording> <output recording>
+//	mlntool diverge [-a V -b V] [-version V] <recording>
+//	mlntool stress [-runs N] [-frames N] [-seed N] [-version N] <output folder>
+//
+// Frame ranges include -from and exclude -to. A -to of 0 means up to the
+// end of the recording.
+//
+// diverge finds the first frame where replaying the recording gives a
+// different world. With -a and -b it compares two versions of the rules
+// compiled into mlntool. Otherwise it compares the rules the recording was
+// played with, or those of -version, against the state hashes stored in the
+// recording when it was played.
+//
+// stress plays levels with random inputs. Each playthrough that crashes is
+// saved in the output folder as crash-<seed>.mln, together with the smallest
+// playthrough found that still crashes, as crash-<seed>-min.mln.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/stress"
+	. "github.com/marisvali/vlok/world"
+	_ "github.com/marisvali/vlok/world/v1"
+	_ "github.com/marisvali/vlok/world/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"info", "info <recording>", info},
+		{"dump", "dump [-from N] [-to N] <recording>", dump},
+		{"export", "export <recording> <output.csv|output.json>", export},
+		{"import", "import [-seed N] [-version N] <input.csv|input.json> <recording>", importInputs},
+		{"extract", "extract -from N -to N <recording> <output recording>", extract},
+		{"diverge", "diverge [-a V -b V] [-version V] <recording>", diverge},
+		{"stress", "stress [-runs N] [-frames N] [-seed N] [-version N] <output folder>", runStress},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, c := range commands {
+		fmt.Fprintln(os.Stderr, "  mlntool "+c.usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			c.run(os.Args[2:])
+			return
+		}
+	}
+	usage()
+}
+
+// parseFlags parses the flags of a command and checks that nArgs arguments
+// are left.
+func parseFlags(fs *flag.FlagSet, args []string, nArgs int) []string {
+	Check(fs.Parse(args))
+	if fs.NArg() != nArgs {
+		usage()
+	}
+	return fs.Args()
+}
+
+// frameRange returns the frames [from, to) of history. A to of 0 means the
+// end of history.
+func frameRange(history []PlayerInput, from, to int) []PlayerInput {
+	if to == 0 || to > len(history) {
+		to = len(history)
+	}
+	if from < 0 || from > to {
+		Check(fmt.Errorf("invalid frame range [%d, %d) for %d frames", from,
+			to, len(history)))
+	}
+	return history[from:to]
+}
+
+func readRecording(filename string) (m Metadata, hasMetadata bool, p Playthrough) {
+	a := OpenArchive(filename)
+	defer a.Close()
+	hasMetadata = a.Has(MetadataEntry)
+	if hasMetadata {
+		m = LoadMetadataFromArchive(a)
+	}
+	p = LoadPlaythroughFromArchive(a)
+	return
+}
+
+func writeRecording(filename string, m Metadata, hasMetadata bool, p Playthrough) {
+	a := CreateArchive(filename)
+	if hasMetadata {
+		m.SaveToArchive(a)
+	}
+	p.SaveToArchive(a)
+	a.Close()
+}
+
+func info(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	args = parseFlags(fs, args, 1)
+	m, hasMetadata, p := readRecording(args[0])
+
+	if hasMetadata {
+		fmt.Printf("user:       %s\n", m.User)
+		fmt.Printf("id:         %s\n", m.Id)
+		fmt.Printf("platform:   %s\n", m.Platform)
+		fmt.Printf("start time: %s\n", m.StartTime)
+		fmt.Printf("end time:   %s\n", m.EndTime)
+		fmt.Printf("duration:   %s\n", m.EndTime.Sub(m.StartTime))
+		fmt.Printf("outcome:    %s\n", m.Outcome)
+	} else {
+		fmt.Println("no metadata")
+	}
+	fmt.Printf("version:    %d\n", p.Version)
+	fmt.Printf("seed:       %d\n", p.Seed.ToInt64())
+	fmt.Printf("frames:     %d\n", len(p.History))
+
+	a := OpenArchive(args[0])
+	defer a.Close()
+	if a.Has(CrashReportEntry) {
+		c := LoadCrashReportFromArchive(a)
+		fmt.Printf("crashed at: frame %d\n", c.Frame)
+		fmt.Printf("crash:      %s\n%s\n", c.Message, c.Stack)
+	}
+}
+
+func dump(args []string) {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	from := fs.Int("from", 0, "first frame")
+	to := fs.Int("to", 0, "frame after the last one, 0 for the end")
+	args = parseFlags(fs, args, 1)
+	_, _, p := readRecording(args[0])
+
+	for i, input := range frameRange(p.History, *from, *to) {
+		fmt.Printf("%d %s\n", *from+i, formatInput(input))
+	}
+}
+
+func export(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	args = parseFlags(fs, args, 2)
+	_, _, p := readRecording(args[0])
+
+	switch strings.ToLower(filepath.Ext(args[1])) {
+	case ".csv":
+		WriteFile(args[1], inputsToCsv(p.History))
+	case ".json":
+		WriteFile(args[1], playthroughToJson(p))
+	default:
+		Check(fmt.Errorf("unknown format for %s, use .csv or .json", args[1]))
+	}
+}
+
+func importInputs(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	seed := fs.Int64("seed", 0, "seed of the level, for CSV files")
+	version := fs.Int64("version", Version, "version of the rules, for CSV files")
+	args = parseFlags(fs, args, 2)
+
+	var p Playthrough
+	data := ReadFile(args[0])
+	switch strings.ToLower(filepath.Ext(args[0])) {
+	case ".csv":
+		p.Version = *version
+		p.Seed = I64(*seed)
+		p.History = inputsFromCsv(data)
+	case ".json":
+		p = playthroughFromJson(data)
+	default:
+		Check(fmt.Errorf("unknown format for %s, use .csv or .json", args[0]))
+	}
+	writeRecording(args[1], Metadata{}, false, p)
+}
+
+func extract(args []string) {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	from := fs.Int("from", 0, "first frame")
+	to := fs.Int("to", 0, "frame after the last one, 0 for the end")
+	args = parseFlags(fs, args, 2)
+	m, hasMetadata, p := readRecording(args[0])
+
+	// The extracted frames still start from the beginning of the level, so
+	// replaying them is only the same as the original if from is 0.
+	if *from > 0 {
+		fmt.Fprintf(os.Stderr, "warning: the output starts at frame %d, but "+
+			"replays from the start of the level, so it won't play out like "+
+			"the original\n", *from)
+	}
+	p.History = frameRange(p.History, *from, *to)
+	// The output is a different playthrough, don't mix it up with the
+	// original.
+	m.Id = uuid.New().String()
+	m.Frames = int64(len(p.History))
+	writeRecording(args[1], m, hasMetadata, p)
+}
+
+func newSimulation(version int64, seed Int) Simulation {
+	s, err := NewSimulation(version, seed)
+	Check(err)
+	return s
+}
+
+func diverge(args []string) {
+	fs := flag.NewFlagSet("diverge", flag.ExitOnError)
+	versionA := fs.Int64("a", 0, "first version of the rules")
+	versionB := fs.Int64("b", 0, "second version of the rules")
+	version := fs.Int64("version", 0, "version of the rules to check "+
+		"against the state hashes, 0 for the version of the recording")
+	args = parseFlags(fs, args, 1)
+	_, _, p := readRecording(args[0])
+
+	var found bool
+	var d Divergence
+	if *versionA != 0 || *versionB != 0 {
+		if *versionA == 0 || *versionB == 0 {
+			usage()
+		}
+		found, d = FindDivergence(newSimulation(*versionA, p.Seed),
+			newSimulation(*versionB, p.Seed), p.History)
+	} else {
+		a := OpenArchive(args[0])
+		hasHashes := a.Has(StateHashesEntry)
+		var hashes []uint64
+		if hasHashes {
+			hashes = LoadStateHashes(a)
+		}
+		a.Close()
+		if !hasHashes {
+			Check(fmt.Errorf("%s has no state hashes, use -a and -b to "+
+				"compare two versions", args[0]))
+		}
+		if *version == 0 {
+			*version = p.Version
+		}
+		found, d = FindHashDivergence(newSimulation(*version, p.Seed),
+			p.History, hashes)
+	}
+
+	if !found {
+		fmt.Println("no divergence")
+		return
+	}
+	if d.Frame < 0 {
+		fmt.Println("diverges before the first frame")
+	} else {
+		fmt.Printf("diverges at frame %d\n", d.Frame)
+	}
+	for _, diff := range d.Diffs {
+		fmt.Println(diff)
+	}
+}
+
+func runStress(args []string) {
+	fs := flag.NewFlagSet("stress", flag.ExitOnError)
+	runs := fs.Int("runs", 100, "number of levels to play")
+	frames := fs.Int("frames", 3600, "number of frames to play in each level")
+	seed := fs.Int64("seed", 0, "seed of the random inputs and of the first level")
+	version := fs.Int64("version", Version, "version of the rules")
+	args = parseFlags(fs, args, 1)
+	Check(os.MkdirAll(args[0], os.ModePerm))
+
+	// The mouse can be anywhere on the screen, so also try positions outside
+	// of the world.
+	size := NewWorld(ZERO).Size
+	cfg := stress.Config{*version, *seed, *runs, *frames,
+		Rectangle{size.Times(I(-1)), size.Times(TWO)}}
+	crashes := stress.Run(cfg)
+	for _, c := range crashes {
+		name := fmt.Sprintf("crash-%d", c.Playthrough.Seed.ToInt64())
+		fmt.Printf("seed %d crashed at frame %d: %s\n",
+			c.Playthrough.Seed.ToInt64(), c.Frame, c.Message)
+		writeCrash(filepath.Join(args[0], name+".mln"), c)
+
+		m := stress.Minimize(c)
+		fmt.Printf("minimized to %d frames, crashed at frame %d: %s\n%s\n",
+			len(m.Playthrough.History), m.Frame, m.Message, m.Stack)
+		writeCrash(filepath.Join(args[0], name+"-min.mln"), m)
+	}
+	fmt.Printf("%d of %d levels crashed\n", len(crashes), *runs)
+}
+
+// writeCrash writes a recording of a crash found by stress, with metadata and
+// a crash report, like the ones the game saves when it crashes.
+func writeCrash(filename string, c stress.Crash) {
+	var m Metadata
+	m.User = "stress"
+	m.Version = c.Playthrough.Version
+	m.Id = uuid.New().String()
+	m.Platform = "native"
+	m.Seed = c.Playthrough.Seed.ToInt64()
+	m.StartTime = time.Now()
+	m.EndTime = m.StartTime
+	m.Frames = int64(len(c.Playthrough.History))
+	m.Outcome = OutcomeCrashed
+
+	var r CrashReport
+	r.Message = c.Message
+	r.Stack = c.Stack
+	r.Frame = int64(c.Frame)
+	if c.State != nil {
+		state, err := json.Marshal(c.State)
+		Check(err)
+		r.State = state
+	}
+
+	a := CreateArchive(filename)
+	m.SaveToArchive(a)
+	c.Playthrough.SaveToArchive(a)
+	r.SaveToArchive(a)
+	a.Close()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/gamelib/db"
+	. "github.com/marisvali/vlok/world"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// crashFolder has the crash bundles which were not uploaded yet.
+const crashFolder = "crashes"
+
+// uploadTimeout is how long to wait for the upload of a crash bundle.
+const uploadTimeout = 10 * time.Second
+
+// recoverCrash must be deferred by Update and Draw. If they panic, it saves a
+// crash bundle and sets *err, so that Update stops the game with an error
+// instead of losing everything.
+func (g *Gui) recoverCrash(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	var c CrashReport
+	c.Message = fmt.Sprint(r)
+	c.Stack = string(debug.Stack())
+	c.Frame = int64(len(g.playthrough.History)) - 1
+	where := g.saveCrash(c)
+	*err = fmt.Errorf("game crashed at frame %d (%s): %s", c.Frame, where,
+		c.Message)
+}
+
+// saveCrash writes a crash bundle: a recording of the current level, with the
+// crash added to it. The bundle is saved in crashFolder, to be uploaded the
+// next time the game starts. If the bundle can't be saved, as in the browser,
+// it is uploaded right away. It returns where the bundle went.
+// The game is in a bad state already, so saving may crash too. In that case
+// the crash is printed to stderr, so that it isn't lost completely.
+func (g *Gui) saveCrash(c CrashReport) (where string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("failed to save crash bundle: %v\ngame crashed at "+
+				"frame %d: %s\n%s", r, c.Frame, c.Message, c.Stack)
+			where = fmt.Sprintf("failed to save: %v, printed to stderr", r)
+		}
+	}()
+
+	// The world may be broken in a way that can't be converted, don't let
+	// that lose the rest of the report.
+	if state, err := json.Marshal(&g.world); err == nil {
+		c.State = state
+	}
+
+	buf := new(bytes.Buffer)
+	a := NewArchiveWriter(buf)
+	g.writeRecording(a, OutcomeCrashed)
+	c.SaveToArchive(a)
+	a.Close()
+
+	filename := filepath.Join(crashFolder, g.metadata.Id+".mln")
+	err := os.MkdirAll(crashFolder, os.ModePerm)
+	if err == nil {
+		err = os.WriteFile(filename, buf.Bytes(), 0644)
+	}
+	if err == nil {
+		return "saved to " + filename
+	}
+	if uploadErr := uploadCrash(g.metadata, buf.Bytes()); uploadErr != nil {
+		return fmt.Sprintf("failed to save: %v, failed to upload: %v", err,
+			uploadErr)
+	}
+	return "uploaded"
+}
+
+// uploadCrash uploads a crash bundle with metadata m. It returns an error
+// instead of crashing, so that a failed upload doesn't stop the game. It
+// doesn't use Check, so it can run in its own goroutine.
+func uploadCrash(m Metadata, data []byte) error {
+	id, err := uuid.Parse(m.Id)
+	if err != nil {
+		return err
+	}
+	return db.TryUploadDataToDbHttp(m.User, m.Version, id, data, uploadTimeout)
+}
+
+// readCrash reads a crash bundle saved by saveCrash and its metadata. It
+// returns an error instead of crashing, as the file may be damaged.
+func readCrash(filename string) (m Metadata, data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	data, err = os.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	m = LoadMetadataFromArchive(NewArchiveReaderFromBytes(data))
+	return
+}
+
+// uploadCrashes uploads the crash bundles saved by previous runs of the game
+// and deletes the ones that were uploaded. The ones that failed are tried
+// again the next time. The bundles are read right away, but the uploads
+// happen in the background, so that they don't delay the game.
+func uploadCrashes() {
+	entries, err := os.ReadDir(crashFolder)
+	if err != nil {
+		// No crashes were saved, or there is no file system.
+		return
+	}
+
+	type bundle struct {
+		filename string
+		m        Metadata
+		data     []byte
+	}
+	var bundles []bundle
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".mln") {
+			continue
+		}
+		filename := filepath.Join(crashFolder, e.Name())
+		m, data, err := readCrash(filename)
+		if err != nil {
+			log.Printf("failed to read crash bundle %s: %v", filename, err)
+			continue
+		}
+		bundles = append(bundles, bundle{filename, m, data})
+	}
+
+	go func() {
+		for _, b := range bundles {
+			if err := uploadCrash(b.m, b.data); err != nil {
+				log.Printf("failed to upload crash bundle %s: %v", b.filename,
+					err)
+				continue
+			}
+			if err := os.Remove(b.filename); err != nil {
+				log.Printf("failed to remove uploaded crash bundle %s: %v",
+					b.filename, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,143 @@
+package gamelib
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// ArchiveWriter writes named entries into a zip archive. Entries are
+// compressed as they are written, so a big entry doesn't need to be fully in
+// memory.
+type ArchiveWriter struct {
+	zw    *zip.Writer
+	file  *os.File
+	names []string
+}
+
+// NewArchiveWriter writes the archive to w. Close must be called at the end,
+// to write the index of the archive.
+func NewArchiveWriter(w io.Writer) *ArchiveWriter {
+	return &ArchiveWriter{zw: zip.NewWriter(w)}
+}
+
+// CreateArchive writes the archive to a new file. Close also closes the file.
+func CreateArchive(filename string) *ArchiveWriter {
+	f, err := os.Create(filename)
+	Check(err)
+	a := NewArchiveWriter(f)
+	a.file = f
+	return a
+}
+
+// Create starts a new entry and returns a writer for its contents. The writer
+// can be used until the next call to Create, Add or Close. It crashes if an
+// entry with the same name was already written.
+func (a *ArchiveWriter) Create(name string) io.Writer {
+	if slices.Contains(a.names, name) {
+		Check(fmt.Errorf("archive already has an entry called %s", name))
+	}
+	a.names = append(a.names, name)
+	w, err := a.zw.Create(name)
+	Check(err)
+	return w
+}
+
+// Add writes an entry whose contents are already in memory.
+func (a *ArchiveWriter) Add(name string, data []byte) {
+	_, err := a.Create(name).Write(data)
+	Check(err)
+}
+
+func (a *ArchiveWriter) Close() {
+	Check(a.zw.Close())
+	if a.file != nil {
+		CloseFile(a.file)
+	}
+}
+
+// ArchiveReader reads named entries from a zip archive. Entries are
+// decompressed as they are read.
+type ArchiveReader struct {
+	zr   *zip.Reader
+	file *os.File
+}
+
+// NewArchiveReader reads the archive from r, which has size bytes.
+func NewArchiveReader(r io.ReaderAt, size int64) *ArchiveReader {
+	zr, err := zip.NewReader(r, size)
+	Check(err)
+	return &ArchiveReader{zr: zr}
+}
+
+// NewArchiveReaderFromBytes reads an archive that is already in memory.
+func NewArchiveReaderFromBytes(data []byte) *ArchiveReader {
+	return NewArchiveReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// OpenArchive reads the archive from a file. Only the parts of the file that
+// are needed are read. Close must be called at the end to close the file.
+func OpenArchive(filename string) *ArchiveReader {
+	f, err := os.Open(filename)
+	Check(err)
+	info, err := f.Stat()
+	Check(err)
+	a := NewArchiveReader(f, info.Size())
+	a.file = f
+	return a
+}
+
+// Names returns the names of the entries, in the order in which they were
+// written.
+func (a *ArchiveReader) Names() (names []string) {
+	for _, f := range a.zr.File {
+		names = append(names, f.Name)
+	}
+	return
+}
+
+func (a *ArchiveReader) Has(name string) bool {
+	return a.find(name) != nil
+}
+
+func (a *ArchiveReader) find(name string) *zip.File {
+	for _, f := range a.zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Open returns a reader for the contents of an entry, which must be closed
+// after use. It crashes if there is no entry with this name.
+func (a *ArchiveReader) Open(name string) io.ReadCloser {
+	f := a.find(name)
+	if f == nil {
+		Check(fmt.Errorf("archive has no entry called %s", name))
+	}
+	rc, err := f.Open()
+	Check(err)
+	return rc
+}
+
+// Read returns all the contents of an entry. It crashes if there is no entry
+// with this name.
+func (a *ArchiveReader) Read(name string) []byte {
+	rc := a.Open(name)
+	defer func(rc io.ReadCloser) { Check(rc.Close()) }(rc)
+	data, err := io.ReadAll(rc)
+	Check(err)
+	return data
+}
+
+// Close closes the file opened by OpenArchive. It does nothing for other
+// archives.
+func (a *ArchiveReader) Close() {
+	if a.file != nil {
+		CloseFile(a.file)
+	}
+}
@@ -0,0 +1,61 @@
+package gamelib
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewArchiveWriter(buf)
+	w.Add("inputs", []byte("some inputs"))
+	w.Add("metadata.json", []byte(`{"user": "someone"}`))
+
+	// Write a big entry in pieces.
+	e := w.Create("hashes")
+	for i := 0; i < 1000; i++ {
+		_, err := e.Write([]byte("0123456789"))
+		assert.Nil(t, err)
+	}
+	assert.Panics(t, func() { w.Add("inputs", nil) })
+	w.Close()
+
+	r := NewArchiveReaderFromBytes(buf.Bytes())
+	assert.Equal(t, []string{"inputs", "metadata.json", "hashes"}, r.Names())
+	assert.True(t, r.Has("inputs"))
+	assert.False(t, r.Has("thumbnail.png"))
+	assert.Equal(t, []byte("some inputs"), r.Read("inputs"))
+	assert.Equal(t, []byte(`{"user": "someone"}`), r.Read("metadata.json"))
+	assert.Panics(t, func() { r.Read("thumbnail.png") })
+
+	// Read a big entry in pieces.
+	rc := r.Open("hashes")
+	piece := make([]byte, 10)
+	for i := 0; i < 1000; i++ {
+		_, err := io.ReadFull(rc, piece)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("0123456789"), piece)
+	}
+	_, err := rc.Read(piece)
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, rc.Close())
+}
+
+func TestArchive_File(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "recording.mln")
+	w := CreateArchive(filename)
+	w.Add("a", []byte("first"))
+	w.Add("b", []byte("second"))
+	w.Close()
+
+	r := OpenArchive(filename)
+	defer r.Close()
+	assert.Equal(t, []byte("second"), r.Read("b"))
+	assert.Equal(t, []byte("first"), r.Read("a"))
+
+	// Zip and Unzip still work with single entry archives only.
+	assert.Panics(t, func() { UnzipFromFile(filename) })
+}
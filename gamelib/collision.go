@@ -0,0 +1,197 @@
+package gamelib
+
+// maxSlides limits how many times a sweep can hit something and slide along
+// it, in one move.
+const maxSlides = 4
+
+// Obstacle is a static shape that moving things collide with.
+type Obstacle interface {
+	Raycastable
+	ContainsPt(pt Pt) bool
+	// Inflated returns the obstacle grown by halfSize.X to the left and right
+	// and by halfSize.Y up and down. Sweeping a point against the inflated
+	// obstacle is the same as sweeping a shape of that size against the
+	// original one.
+	Inflated(halfSize Pt) Obstacle
+}
+
+// Inflated implements Obstacle.
+func (r *Rectangle) Inflated(halfSize Pt) Obstacle {
+	return &Rectangle{r.Min().Minus(halfSize), r.Max().Plus(halfSize)}
+}
+
+// Inflated implements Obstacle. The circle grows by the largest of
+// halfSize.X and halfSize.Y, so it stays a circle.
+func (c Circle) Inflated(halfSize Pt) Obstacle {
+	grow := Max(halfSize.X, halfSize.Y)
+	return Circle{c.Center, c.Diameter.Plus(grow.Times(TWO))}
+}
+
+func (s Square) ContainsPt(pt Pt) bool {
+	r := s.ToRectangle()
+	return r.ContainsPt(pt)
+}
+
+// Inflated implements Obstacle.
+func (s Square) Inflated(halfSize Pt) Obstacle {
+	r := s.ToRectangle()
+	return r.Inflated(halfSize)
+}
+
+// SweepPt moves pos by delta and returns the new position. If the move hits
+// an obstacle, pos stops right before it and the rest of the move continues
+// along the surface that was hit, so that things slide along walls instead
+// of getting stuck. Obstacles that already contain pos are ignored.
+func SweepPt(pos, delta Pt, obstacles []Obstacle) Pt {
+	return sweep(nil, pos, delta, obstacles)
+}
+
+// SweepPtInArea moves pos by delta like SweepPt, but also keeps it inside
+// area. When the move gets to the edge of the area, it continues towards the
+// point of the area closest to pos + delta. For convex areas, this is the
+// same as sliding along the edge. For other areas, the closest point may be
+// on the other side of a wall, so the move stops at the wall. If pos starts
+// outside the area, it goes towards the point of the area closest to
+// pos + delta.
+func SweepPtInArea(area Area, pos, delta Pt, obstacles []Obstacle) Pt {
+	return sweep(area, pos, delta, obstacles)
+}
+
+// sweep implements SweepPt and SweepPtInArea. area is nil for SweepPt.
+func sweep(area Area, pos, delta Pt, obstacles []Obstacle) Pt {
+	shapes := make([]Raycastable, len(obstacles))
+	for i := range obstacles {
+		shapes[i] = obstacles[i]
+	}
+	contains := func(pt Pt) bool {
+		for _, o := range obstacles {
+			if o.ContainsPt(pt) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < maxSlides && delta != (Pt{}); i++ {
+		target := pos.Plus(delta)
+		if area != nil && !area.ContainsPt(pos) {
+			target = area.ClosestPt(target)
+		}
+		ok, hit := Raycast(Line{pos, target}, shapes)
+
+		// If the move leaves the area before hitting an obstacle, stop at the
+		// edge of the area and go towards the point of the area closest to
+		// the target, which slides along the edge.
+		if area != nil && area.ContainsPt(pos) {
+			exit := lastInside(area, pos, target)
+			if exit != target && (!ok ||
+				pos.SquaredDistTo(exit).Lt(pos.SquaredDistTo(hit.Pt))) {
+				pos = exit
+				delta = exit.To(area.ClosestPt(target))
+				continue
+			}
+		}
+		if !ok {
+			return target
+		}
+
+		// Obstacles include their edges, so back off from the hit point
+		// until we are outside all of them.
+		stop := hit.Pt
+		step := Pt{sign(pos.X.Minus(stop.X)), sign(pos.Y.Minus(stop.Y))}
+		for contains(stop) && stop != pos {
+			stop.Add(step)
+			if stop.X.Eq(pos.X) {
+				step.X = ZERO
+			}
+			if stop.Y.Eq(pos.Y) {
+				step.Y = ZERO
+			}
+		}
+
+		// Keep the part of the remaining move that goes along the surface.
+		rest := stop.To(target)
+		tangent := Pt{hit.Normal.Y.Negative(), hit.Normal.X}
+		pos = stop
+		delta = tangent.Times(rest.Dot(tangent)).DivBy(tangent.SquaredLen())
+	}
+	return pos
+}
+
+// SweepBoxInArea moves the rectangle of size boxSize centered at center by
+// delta, like SweepPtInArea, and returns the new center. area limits where
+// the center can be.
+func SweepBoxInArea(area Area, center, boxSize, delta Pt, obstacles []Obstacle) Pt {
+	return SweepPtInArea(area, center, delta, inflateAll(obstacles, boxSize.DivBy(TWO)))
+}
+
+// SweepCircle moves c by delta, like SweepPt, and returns the new center.
+func SweepCircle(c Circle, delta Pt, obstacles []Obstacle) Pt {
+	r := c.Radius()
+	return SweepPt(c.Center, delta, inflateAll(obstacles, Pt{r, r}))
+}
+
+// SweepBox moves the rectangle of size boxSize centered at center by delta,
+// like SweepPt, and returns the new center.
+func SweepBox(center, boxSize, delta Pt, obstacles []Obstacle) Pt {
+	return SweepPt(center, delta, inflateAll(obstacles, boxSize.DivBy(TWO)))
+}
+
+func inflateAll(obstacles []Obstacle, halfSize Pt) []Obstacle {
+	inflated := make([]Obstacle, len(obstacles))
+	for i := range obstacles {
+		inflated[i] = obstacles[i].Inflated(halfSize)
+	}
+	return inflated
+}
+
+func sign(a Int) Int {
+	if a.IsPositive() {
+		return ONE
+	}
+	if a.IsNegative() {
+		return I(-1)
+	}
+	return ZERO
+}
+
+// lastInside goes from start to end and returns the last point inside area
+// before the segment leaves it. start must be inside area.
+// The segment is checked every Unit, so thin parts of the area may be
+// skipped.
+func lastInside(area Area, start, end Pt) Pt {
+	dir := start.To(end)
+	n := Max(dir.X.Abs(), dir.Y.Abs())
+	if n.IsZero() {
+		return end
+	}
+	at := func(t Int) Pt {
+		return start.Plus(dir.Times(t).DivBy(n))
+	}
+
+	// Find the first step that ends outside.
+	lo := ZERO
+	hi := n
+	for t := I(Unit); ; t.Add(I(Unit)) {
+		t = Min(t, n)
+		if !area.ContainsPt(at(t)) {
+			hi = t
+			break
+		}
+		if t.Eq(n) {
+			return end
+		}
+		lo = t
+	}
+
+	// at(lo) is inside, at(hi) is outside.
+	for lo.Plus(ONE).Lt(hi) {
+		mid := lo.Plus(hi).DivBy(TWO)
+		if area.ContainsPt(at(mid)) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return at(lo)
+}
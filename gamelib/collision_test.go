@@ -0,0 +1,122 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSweepPt(t *testing.T) {
+	wall := Rectangle{IPt(10, -100), IPt(20, 100)}
+	obstacles := []Obstacle{&wall}
+
+	// Nothing in the way.
+	assert.Equal(t, IPt(5, 5), SweepPt(IPt(0, 0), IPt(5, 5), obstacles))
+
+	// Straight into the wall, stop right before it.
+	assert.Equal(t, IPt(9, 0), SweepPt(IPt(0, 0), IPt(30, 0), obstacles))
+
+	// Diagonally into the wall, slide along it.
+	pos := SweepPt(IPt(0, 0), IPt(30, 30), obstacles)
+	assert.Equal(t, I(9), pos.X)
+	assert.Equal(t, I(30), pos.Y)
+	assert.False(t, wall.ContainsPt(pos))
+
+	// Slide along a circle, never going inside.
+	c := Circle{IPt(50, 0), I(40)}
+	pos = SweepPt(IPt(0, 5), IPt(100, 0), []Obstacle{c})
+	assert.False(t, c.ContainsPt(pos))
+	assert.True(t, pos.X.Gt(I(20)))
+	assert.True(t, pos.Y.Gt(I(5)))
+}
+
+func TestSweepPt_Corner(t *testing.T) {
+	// Two walls meet in a corner. Moving into the corner stops there.
+	obstacles := []Obstacle{
+		&Rectangle{IPt(10, -100), IPt(20, 100)},
+		&Rectangle{IPt(-100, 10), IPt(20, 20)},
+	}
+	pos := SweepPt(IPt(0, 0), IPt(40, 30), obstacles)
+	assert.Equal(t, IPt(9, 9), pos)
+}
+
+func TestSweepBox(t *testing.T) {
+	wall := Rectangle{IPt(10, -100), IPt(20, 100)}
+	obstacles := []Obstacle{&wall}
+
+	// The edge of the box stops before the wall.
+	pos := SweepBox(IPt(-20, 0), IPt(10, 10), IPt(100, 0), obstacles)
+	assert.Equal(t, IPt(4, 0), pos)
+
+	pos = SweepCircle(Circle{IPt(-20, 0), I(10)}, IPt(100, 0), obstacles)
+	assert.Equal(t, IPt(4, 0), pos)
+
+	// Squares are obstacles too.
+	pos = SweepPt(IPt(0, 0), IPt(100, 0), []Obstacle{Square{IPt(50, 0), I(20)}})
+	assert.Equal(t, IPt(39, 0), pos)
+}
+
+func TestSweepPtInArea_NoObstacles(t *testing.T) {
+	room := Rectangle{IPt(0, 0), IPt(100, 100)}
+
+	// Inside, move freely.
+	assert.Equal(t, IPt(60, 60), SweepPtInArea(&room, IPt(50, 50), IPt(10, 10), nil))
+
+	// Slide along the right wall.
+	assert.Equal(t, IPt(100, 70), SweepPtInArea(&room, IPt(90, 50), IPt(20, 20), nil))
+
+	// Straight into a wall, stop at it.
+	assert.Equal(t, IPt(100, 50), SweepPtInArea(&room, IPt(90, 50), IPt(20, 0), nil))
+
+	// Start outside, go to the closest point.
+	assert.Equal(t, IPt(100, 50), SweepPtInArea(&room, IPt(200, 50), IPt(10, 0), nil))
+
+	// In an L shaped room, don't cut through the inner corner. The area is
+	// checked every Unit, so use a room of realistic size.
+	var l Polygon
+	for _, pt := range lShape().Pts {
+		l.Pts = append(l.Pts, UPt(pt.X.ToInt(), pt.Y.ToInt()))
+	}
+	pos := SweepPtInArea(l, UPt(10, 30), UPt(30, 0), nil)
+	assert.Equal(t, UPt(20, 30), pos)
+}
+
+func TestSweepPtInArea_Slanted(t *testing.T) {
+	triangle := Polygon{[]Pt{IPt(0, 0), IPt(1000, 0), IPt(0, 1000)}}
+	pos := SweepPtInArea(triangle, IPt(400, 400), IPt(300, 0), nil)
+	assert.True(t, triangle.ContainsPt(pos))
+	assert.True(t, pos.X.Gt(I(550)))
+	assert.True(t, pos.Y.Lt(I(400)))
+	assert.True(t, pos.X.Plus(pos.Y).Geq(I(995)))
+}
+
+func TestSweepPtInArea(t *testing.T) {
+	room := Rectangle{IPt(0, 0), IPt(100, 100)}
+	wall := Rectangle{IPt(60, 0), IPt(70, 40)}
+	obstacles := []Obstacle{&wall}
+
+	// The edge of the area stops the move.
+	pos := SweepPtInArea(&room, IPt(90, 50), IPt(20, 20), obstacles)
+	assert.Equal(t, IPt(100, 70), pos)
+
+	// So do obstacles inside the area.
+	pos = SweepPtInArea(&room, IPt(10, 20), IPt(100, 0), obstacles)
+	assert.Equal(t, IPt(59, 20), pos)
+
+	// Sliding along the edge of the area stops at obstacles.
+	pos = SweepPtInArea(&room, IPt(50, 10), IPt(0, -20), obstacles)
+	assert.Equal(t, IPt(50, 0), pos)
+	pos = SweepPtInArea(&room, IPt(50, 10), IPt(30, -20), obstacles)
+	assert.Equal(t, IPt(59, 0), pos)
+
+	// Start outside, go towards the closest point.
+	pos = SweepPtInArea(&room, IPt(200, 50), IPt(10, 0), obstacles)
+	assert.Equal(t, IPt(100, 50), pos)
+}
+
+func TestSweepBoxInArea_Slanted(t *testing.T) {
+	triangle := Polygon{[]Pt{IPt(0, 0), IPt(1000, 0), IPt(0, 1000)}}
+	pos := SweepBoxInArea(triangle, IPt(400, 400), IPt(20, 20), IPt(300, 0), nil)
+	assert.True(t, triangle.ContainsPt(pos))
+	assert.True(t, pos.X.Gt(I(550)))
+	assert.True(t, pos.X.Plus(pos.Y).Geq(I(995)))
+}
@@ -0,0 +1,141 @@
+// Package db uploads and downloads playthroughs, either through the website
+// or directly from the MySQL database.
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	. "github.com/marisvali/vlok/gamelib"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+func sendDataToDbHttp(user string, version int64, id uuid.UUID, data []byte) {
+	Check(trySendDataToDbHttp(user, version, id, data, 0))
+}
+
+// trySendDataToDbHttp sends data and returns an error instead of crashing if
+// it fails. A timeout of 0 means no timeout.
+func trySendDataToDbHttp(user string, version int64, id uuid.UUID, data []byte,
+	timeout time.Duration) error {
+	url := "https://playful-patterns.com/submit-playthrough.php"
+
+	// Create a buffer to write our multipart form data.
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+	if err := writer.WriteField("user", user); err != nil {
+		return err
+	}
+	if err := writer.WriteField("version", strconv.FormatInt(version, 10)); err != nil {
+		return err
+	}
+	if err := writer.WriteField("id", id.String()); err != nil {
+		return err
+	}
+	if data != nil {
+		part, err := writer.CreateFormFile("playthrough", "rima")
+		if err != nil {
+			return err
+		}
+		if _, err = part.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	// Create a POST request with the multipart form data.
+	request, err := http.NewRequest("POST", url, &requestBody)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("content-type", writer.FormDataContentType())
+
+	// Perform the request.
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 200 {
+		return fmt.Errorf("http request failed: %d", response.StatusCode)
+	}
+	return nil
+}
+
+func InitializeIdInDbHttp(user string, version int64, id uuid.UUID) {
+	sendDataToDbHttp(user, version, id, nil)
+}
+
+func UploadDataToDbHttp(user string, version int64, id uuid.UUID, data []byte) {
+	sendDataToDbHttp(user, version, id, data)
+}
+
+// TryUploadDataToDbHttp is like UploadDataToDbHttp, but returns an error
+// instead of crashing and gives up after timeout. It doesn't use Check, so it
+// can run in its own goroutine.
+func TryUploadDataToDbHttp(user string, version int64, id uuid.UUID,
+	data []byte, timeout time.Duration) error {
+	return trySendDataToDbHttp(user, version, id, data, timeout)
+}
+
+func ConnectToDbSql() *sql.DB {
+	cfg := mysql.Config{
+		User:                 os.Getenv("MILN_DBUSER"),
+		Passwd:               os.Getenv("MILN_DBPASSWORD"),
+		Net:                  "tcp",
+		Addr:                 os.Getenv("MILN_DBADDR"),
+		DBName:               os.Getenv("MILN_DBNAME"),
+		AllowNativePasswords: true,
+		ParseTime:            true,
+	}
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	Check(err)
+	err = db.Ping()
+	Check(err)
+	return db
+}
+
+func InitializeIdInDbSql(db *sql.DB, id uuid.UUID) {
+	_, err := db.Exec("INSERT INTO playthroughs (id) VALUES (?)", id.String())
+	Check(err)
+}
+
+func UploadDataToDbSql(db *sql.DB, id uuid.UUID, data []byte) {
+	_, err := db.Exec("UPDATE playthroughs SET playthrough = ? WHERE id = ?", data, id.String())
+	Check(err)
+}
+
+func DownloadDataFromDbSql(db *sql.DB, id uuid.UUID) (data []byte) {
+	rows, err := db.Query("SELECT playthrough FROM playthroughs WHERE id = ?", id.String())
+	Check(err)
+	defer func(rows *sql.Rows) { Check(rows.Close()) }(rows)
+	if !rows.Next() {
+		Check(fmt.Errorf("id not found: %s", id.String()))
+	}
+	err = rows.Scan(&data)
+	Check(err)
+	return
+}
+
+func InspectDataFromDbSql(db *sql.DB) {
+	rows, err := db.Query("SELECT * FROM playthroughs")
+	Check(err)
+	defer func(rows *sql.Rows) { Check(rows.Close()) }(rows)
+
+	for rows.Next() {
+		var data []byte
+		err := rows.Scan(&data)
+		Check(err)
+		println(len(data))
+	}
+}
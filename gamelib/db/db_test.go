@@ -0,0 +1,27 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDbSql(t *testing.T) {
+	db := ConnectToDbSql()
+	id := uuid.New()
+	InitializeIdInDbSql(db, id)
+	UploadDataToDbSql(db, id, []byte("what do you mean"))
+	InspectDataFromDbSql(db)
+	assert.True(t, true)
+}
+
+func TestDbHttp(t *testing.T) {
+	id := uuid.New()
+	// id, err := uuid.Parse("550e8400-e29b-41d4-a716-446655440002")
+	// Check(err)
+	InitializeIdInDbHttp("test-user", 19, id)
+	UploadDataToDbHttp("test-user", 19, id, []byte("mele 1"))
+	UploadDataToDbHttp("test-user", 19, id, []byte("mele 2"))
+	UploadDataToDbHttp("test-user", 19, id, []byte("mele totusi, da -------"))
+	assert.Equal(t, true, true)
+}
@@ -0,0 +1,206 @@
+package gamelib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// FieldDiff is a difference between two values found by Diff.
+type FieldDiff struct {
+	// Path to the field that differs, like "Character.Pos.X" or
+	// "History[3]".
+	Path string
+	A    string
+	B    string
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Path, d.A, d.B)
+}
+
+// Diff compares a and b field by field, including unexported fields, and
+// returns the fields that differ. Int and types that implement fmt.Stringer
+// are compared as a whole, and Int is shown as a plain number. Pointers are
+// followed, so a and b can be pointers to structs.
+// a and b don't need to have the same type. Structs are compared by the
+// names of their fields, so states from two versions of the same code can be
+// compared. Fields that exist in only one of them are reported as missing in
+// the other.
+func Diff(a, b any) (diffs []FieldDiff) {
+	diffValues("", reflect.ValueOf(a), reflect.ValueOf(b), &diffs)
+	return
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+var intType = reflect.TypeOf(Int{})
+
+func formatValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<missing>"
+	}
+	if v.Type() == intType {
+		return strconv.FormatInt(v.Field(0).Int(), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+func isStruct(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct && v.Type() != intType &&
+		!v.Type().Implements(stringerType)
+}
+
+func diffValues(path string, a, b reflect.Value, diffs *[]FieldDiff) {
+	leafDiff := func() {
+		*diffs = append(*diffs, FieldDiff{path, formatValue(a), formatValue(b)})
+	}
+	if !a.IsValid() || !b.IsValid() || a.Kind() != b.Kind() {
+		if a.IsValid() || b.IsValid() {
+			leafDiff()
+		}
+		return
+	}
+
+	switch {
+	case isStruct(a) && isStruct(b):
+		for _, name := range fieldNames(a.Type(), b.Type()) {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			diffValues(fieldPath, a.FieldByName(name), b.FieldByName(name), diffs)
+		}
+	case a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				leafDiff()
+			}
+			return
+		}
+		diffValues(path, a.Elem(), b.Elem(), diffs)
+	case a.Kind() == reflect.Slice || a.Kind() == reflect.Array:
+		if a.Len() != b.Len() {
+			*diffs = append(*diffs, FieldDiff{path + ".len",
+				fmt.Sprint(a.Len()), fmt.Sprint(b.Len())})
+		}
+		for i := 0; i < min(a.Len(), b.Len()); i++ {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i), diffs)
+		}
+	case a.Kind() == reflect.Map && a.Type() == b.Type():
+		for _, k := range mapKeys(a, b) {
+			diffValues(fmt.Sprintf("%s[%v]", path, k), a.MapIndex(k),
+				b.MapIndex(k), diffs)
+		}
+	default:
+		if formatValue(a) != formatValue(b) {
+			leafDiff()
+		}
+	}
+}
+
+// fieldNames returns the names of the fields of a, followed by the names of
+// the fields that only b has.
+func fieldNames(a, b reflect.Type) (names []string) {
+	for i := 0; i < a.NumField(); i++ {
+		names = append(names, a.Field(i).Name)
+	}
+	for i := 0; i < b.NumField(); i++ {
+		if !slices.Contains(names, b.Field(i).Name) {
+			names = append(names, b.Field(i).Name)
+		}
+	}
+	return
+}
+
+// mapKeys returns the keys of both maps, sorted by how they print, so that
+// results don't depend on the order in which maps are iterated.
+func mapKeys(a, b reflect.Value) (keys []reflect.Value) {
+	seen := map[string]bool{}
+	for _, m := range []reflect.Value{a, b} {
+		for _, k := range m.MapKeys() {
+			s := fmt.Sprint(k)
+			if !seen[s] {
+				seen[s] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.SortFunc(keys, func(k1, k2 reflect.Value) int {
+		s1, s2 := fmt.Sprint(k1), fmt.Sprint(k2)
+		if s1 < s2 {
+			return -1
+		}
+		if s1 > s2 {
+			return 1
+		}
+		return 0
+	})
+	return
+}
+
+// HashState returns a hash of all the fields of v, including unexported
+// fields, so that two values with the same contents have the same hash.
+// It's meant for checking that a simulation gives the same states when it
+// runs again, so it only needs to be the same for the same build on any
+// platform.
+func HashState(v any) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	write := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf, x)
+		_, _ = h.Write(buf)
+	}
+	var hashValue func(v reflect.Value)
+	hashValue = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Invalid:
+			write(0)
+		case reflect.Bool:
+			if v.Bool() {
+				write(1)
+			} else {
+				write(0)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			write(uint64(v.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+			reflect.Uint64, reflect.Uintptr:
+			write(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			write(math.Float64bits(v.Float()))
+		case reflect.String:
+			write(uint64(v.Len()))
+			_, _ = h.Write([]byte(v.String()))
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				hashValue(v.Field(i))
+			}
+		case reflect.Slice, reflect.Array:
+			write(uint64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				hashValue(v.Index(i))
+			}
+		case reflect.Pointer, reflect.Interface:
+			if v.IsNil() {
+				write(0)
+			} else {
+				write(1)
+				hashValue(v.Elem())
+			}
+		case reflect.Map:
+			write(uint64(v.Len()))
+			for _, k := range mapKeys(v, v) {
+				hashValue(k)
+				hashValue(v.MapIndex(k))
+			}
+		default:
+			Check(fmt.Errorf("cannot hash values of kind %s", v.Kind()))
+		}
+	}
+	hashValue(reflect.ValueOf(v))
+	return h.Sum64()
+}
@@ -0,0 +1,94 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type diffTestState struct {
+	Pos     Pt
+	Health  Int
+	Names   []string
+	Scores  map[string]int
+	Next    *diffTestState
+	private MatBool
+}
+
+func newDiffTestState() diffTestState {
+	return diffTestState{
+		Pos:     IPt(1, 2),
+		Health:  I(3),
+		Names:   []string{"a", "b"},
+		Scores:  map[string]int{"x": 1, "y": 2},
+		Next:    &diffTestState{Health: I(1)},
+		private: NewMatBool(IPt(3, 3)),
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := newDiffTestState()
+	b := newDiffTestState()
+	assert.Empty(t, Diff(&a, &b))
+	assert.Equal(t, HashState(&a), HashState(&b))
+
+	b.Pos.Y = I(5)
+	b.Health = I(2)
+	b.Names = append(b.Names, "c")
+	b.Names[0] = "z"
+	b.Scores["y"] = 3
+	b.Scores["w"] = 0
+	b.Next.Health = I(0)
+	b.private.Set(IPt(1, 1))
+	assert.Equal(t, []FieldDiff{
+		{"Pos.Y", "2", "5"},
+		{"Health", "3", "2"},
+		{"Names.len", "2", "3"},
+		{"Names[0]", "a", "z"},
+		{"Scores[w]", "<missing>", "0"},
+		{"Scores[y]", "2", "3"},
+		{"Next.Health", "1", "0"},
+		{"private.words[0]", "0", "16"},
+	}, Diff(&a, &b))
+	assert.Equal(t, "Pos.Y: 2 != 5", Diff(a, b)[0].String())
+}
+
+func TestDiff_DifferentTypes(t *testing.T) {
+	type oldState struct {
+		Pos    Pt
+		Health Int
+		Speed  Int
+	}
+	type newState struct {
+		Pos    Pt
+		Health Int
+		Armor  Int
+	}
+	a := oldState{IPt(1, 1), I(3), I(5)}
+	b := newState{IPt(1, 2), I(3), I(1)}
+	assert.Equal(t, []FieldDiff{
+		{"Pos.Y", "1", "2"},
+		{"Speed", "5", "<missing>"},
+		{"Armor", "<missing>", "1"},
+	}, Diff(&a, &b))
+}
+
+func TestHashState(t *testing.T) {
+	a := newDiffTestState()
+	b := newDiffTestState()
+
+	// Every change gives a different hash.
+	hashes := map[uint64]bool{HashState(&a): true}
+	changes := []func(){
+		func() { b.Pos.X.Inc() },
+		func() { b.Names[1] = "" },
+		func() { b.Scores["x"] = 5 },
+		func() { b.Next = nil },
+		func() { b.private.Set(IPt(2, 2)) },
+	}
+	for _, change := range changes {
+		change()
+		h := HashState(&b)
+		assert.False(t, hashes[h])
+		hashes[h] = true
+	}
+}
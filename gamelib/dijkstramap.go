@@ -0,0 +1,207 @@
+package gamelib
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+/*
+DijkstraMap is a distance field computed over the same graph used by
+Pathfinding.
+
+The problem:
+Several agents want to go to the same place (e.g. all creatures want to get to
+the food). Calling FindPath for each agent does the same work over and over.
+
+The solution:
+Do a single breadth-first search starting from all the goals at the same time
+and remember, for each position, how many steps it takes to get to the closest
+goal. Each agent then only needs to look at its neighbors and step towards the
+one with the smallest value. This is what roguelikes usually call a Dijkstra
+map.
+
+The same trick gives agents a way to run away from the goals: multiply every
+value by a negative factor and scan the map again. The agents still go
+downhill, but now downhill means away from the goals. The extra scan is what
+makes the agents prefer escape routes over dead ends, as opposed to just
+running into the closest corner.
+*/
+
+// unreachable marks the positions that can't reach any goal.
+var unreachable = I64(math.MaxInt64)
+
+// These define the factor by which a map is multiplied when turned into a
+// flee map. The factor is -1.2, the value usually recommended for Dijkstra
+// maps. It needs to be smaller than -1 so that agents are willing to pass
+// close to a goal if that leads them to a place that is farther away.
+var fleeMultiply = I(-6)
+var fleeDivide = I(5)
+
+type DijkstraMap struct {
+	// These are shared with the Pathfinding that created the map and must
+	// never be modified.
+	neighbors []int
+	nDirs     int
+	dist      Matrix[Int]
+}
+
+// DijkstraMap computes the number of steps from every position to the
+// closest of the goals.
+func (p *Pathfinding[T]) DijkstraMap(goals []Pt) (d DijkstraMap) {
+	d.neighbors = p.neighbors
+	d.nDirs = p.nDirs
+	d.dist = NewMatrix[Int](p.m.Size())
+	for i := range d.dist.cells {
+		d.dist.cells[i] = unreachable
+	}
+
+	seeds := make([]int, 0, len(goals))
+	for _, goal := range goals {
+		d.checkInBounds(goal)
+		idx := d.dist.PtToIndex(goal).ToInt()
+		d.dist.cells[idx] = ZERO
+		seeds = append(seeds, idx)
+	}
+	d.scan(seeds)
+	return
+}
+
+func (d *DijkstraMap) checkInBounds(pt Pt) {
+	if !d.dist.InBounds(pt) {
+		size := d.dist.Size()
+		Check(fmt.Errorf("position (%d, %d) is outside map of size (%d, %d)",
+			pt.X.ToInt(), pt.Y.ToInt(), size.X.ToInt(), size.Y.ToInt()))
+	}
+}
+
+// scan propagates the values of the seeds to all the positions reachable from
+// them. A position ends up with the smallest value it can get from any seed,
+// plus one for each step needed to reach it from that seed.
+func (d *DijkstraMap) scan(seeds []int) {
+	// Remember the values of the seeds, as the values in d.dist will change
+	// while scanning.
+	type seed struct {
+		idx int
+		val Int
+	}
+	sorted := make([]seed, len(seeds))
+	for i := range seeds {
+		sorted[i] = seed{seeds[i], d.dist.cells[seeds[i]]}
+	}
+	slices.SortStableFunc(sorted, func(a, b seed) int {
+		if a.val.Lt(b.val) {
+			return -1
+		}
+		if a.val.Gt(b.val) {
+			return 1
+		}
+		return 0
+	})
+
+	// This is a breadth-first search where nodes come from two queues. The
+	// values in both queues only ever increase, so always taking the smallest
+	// value from the front of the two queues means we process nodes in order
+	// of their final values. This is what Dijkstra's algorithm does, without
+	// needing a priority queue.
+	done := make([]bool, len(d.dist.cells))
+	queue := make([]int, 0, len(d.dist.cells))
+	iSeed := 0
+	iQueue := 0
+	for iSeed < len(sorted) || iQueue < len(queue) {
+		var node int
+		if iQueue == len(queue) ||
+			(iSeed < len(sorted) && sorted[iSeed].val.Leq(d.dist.cells[queue[iQueue]])) {
+			node = sorted[iSeed].idx
+			iSeed++
+		} else {
+			node = queue[iQueue]
+			iQueue++
+		}
+
+		if done[node] {
+			continue
+		}
+		done[node] = true
+
+		val := d.dist.cells[node].Plus(ONE)
+		nIndex := node * d.nDirs
+		ns := d.neighbors[nIndex : nIndex+d.nDirs]
+		for _, n := range ns {
+			if n >= 0 && !done[n] && val.Lt(d.dist.cells[n]) {
+				d.dist.cells[n] = val
+				queue = append(queue, n)
+			}
+		}
+	}
+}
+
+// Flee returns a map that leads agents away from the goals of d.
+func (d *DijkstraMap) Flee() (f DijkstraMap) {
+	f.neighbors = d.neighbors
+	f.nDirs = d.nDirs
+	f.dist = d.dist.Clone()
+
+	seeds := []int{}
+	for i := range f.dist.cells {
+		if f.dist.cells[i].Neq(unreachable) {
+			f.dist.cells[i] = f.dist.cells[i].Times(fleeMultiply).DivBy(fleeDivide)
+			seeds = append(seeds, i)
+		}
+	}
+	f.scan(seeds)
+	return
+}
+
+// Reachable returns true if at least one goal can be reached from pt.
+func (d *DijkstraMap) Reachable(pt Pt) bool {
+	d.checkInBounds(pt)
+	return d.dist.Get(pt).Neq(unreachable)
+}
+
+// Dist returns the value of the map at pt. For maps created with
+// Pathfinding.DijkstraMap, this is the number of steps to the closest goal.
+// The position must be reachable.
+func (d *DijkstraMap) Dist(pt Pt) Int {
+	d.checkInBounds(pt)
+	return d.dist.Get(pt)
+}
+
+// NextStep returns the neighbor of pt which an agent at pt should move to.
+// It returns false if no neighbor is better than pt, which happens when the
+// agent reached a goal, when it reached a local minimum of a flee map or
+// when no goal is reachable.
+func (d *DijkstraMap) NextStep(pt Pt) (bool, Pt) {
+	d.checkInBounds(pt)
+	node := d.dist.PtToIndex(pt).ToInt()
+	best := node
+	nIndex := node * d.nDirs
+	ns := d.neighbors[nIndex : nIndex+d.nDirs]
+	for _, n := range ns {
+		// Use < and not <= so that ties are won by the first direction, which
+		// means straight lines get priority, same as in FindPath.
+		if n >= 0 && d.dist.cells[n].Lt(d.dist.cells[best]) {
+			best = n
+		}
+	}
+	if best == node {
+		return false, pt
+	}
+	return true, d.dist.IndexToPt(I(best))
+}
+
+// NextDir returns the direction in which an agent at pt should move, as one of
+// the values returned by Directions8. See NextStep.
+func (d *DijkstraMap) NextDir(pt Pt) (bool, Pt) {
+	ok, next := d.NextStep(pt)
+	if !ok {
+		return false, Pt{}
+	}
+	return true, pt.To(next)
+}
+
+// ToMatrix returns the values of the map. Positions that can't reach any
+// goal have the value math.MaxInt64.
+func (d *DijkstraMap) ToMatrix() Matrix[Int] {
+	return d.dist.Clone()
+}
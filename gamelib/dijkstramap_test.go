@@ -0,0 +1,100 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDijkstraMap(t *testing.T) {
+	m := MatrixFromString(`
+-----
+-xxx-
+-----
+`, map[byte]bool{'x': true, '-': false})
+	p := NewPathfinding(m, false)
+	d := p.DijkstraMap([]Pt{IPt(0, 0), IPt(4, 2)})
+
+	assert.Equal(t, ZERO, d.Dist(IPt(0, 0)))
+	assert.Equal(t, ZERO, d.Dist(IPt(4, 2)))
+	assert.Equal(t, I(2), d.Dist(IPt(2, 0)))
+	assert.Equal(t, I(1), d.Dist(IPt(0, 1)))
+	assert.Equal(t, I(1), d.Dist(IPt(3, 2)))
+	assert.False(t, d.Reachable(IPt(2, 1)))
+
+	// Following the map gets us to a goal in as many steps as the map says.
+	pos := IPt(2, 2)
+	steps := 0
+	for {
+		ok, next := d.NextStep(pos)
+		if !ok {
+			break
+		}
+		pos = next
+		steps++
+	}
+	assert.Equal(t, IPt(4, 2), pos)
+	assert.Equal(t, 2, steps)
+
+	ok, dir := d.NextDir(IPt(0, 2))
+	assert.True(t, ok)
+	assert.Equal(t, IPt(0, -1), dir)
+
+	ok, _ = d.NextStep(IPt(0, 0))
+	assert.False(t, ok)
+}
+
+func TestDijkstraMap_AgreesWithFindPath(t *testing.T) {
+	m := MatrixFromString(`
+--------
+-xxxxx--
+-----x--
+-xxx-x--
+---x----
+`, map[byte]bool{'x': true, '-': false})
+	p := NewPathfinding(m, false)
+	goal := IPt(6, 0)
+	d := p.DijkstraMap([]Pt{goal})
+	for y := 0; y < m.Size().Y.ToInt(); y++ {
+		for x := 0; x < m.Size().X.ToInt(); x++ {
+			pt := IPt(x, y)
+			if m.Get(pt) {
+				continue
+			}
+			path := p.FindPath(pt, goal)
+			assert.Equal(t, I(len(path)-1), d.Dist(pt))
+		}
+	}
+}
+
+func TestDijkstraMap_Flee(t *testing.T) {
+	m := NewMatrix[bool](IPt(7, 1))
+	p := NewPathfinding(m, false)
+	d := p.DijkstraMap([]Pt{IPt(2, 0)})
+	f := d.Flee()
+
+	// The flee map is lowest at the end that is farthest from the goal.
+	assert.Equal(t, I(-4*6/5), f.Dist(IPt(6, 0)))
+
+	// Running away from the goal leads to the farthest end.
+	pos := IPt(3, 0)
+	for {
+		ok, next := f.NextStep(pos)
+		if !ok {
+			break
+		}
+		pos = next
+	}
+	assert.Equal(t, IPt(6, 0), pos)
+}
+
+func TestDijkstraMap_OutOfBounds(t *testing.T) {
+	m := NewMatrix[bool](IPt(4, 3))
+	p := NewPathfinding(m, false)
+	// Index 4 is (0, 1), so without a check these would pick the wrong
+	// position instead of failing.
+	assert.Panics(t, func() { p.DijkstraMap([]Pt{IPt(4, 0)}) })
+	d := p.DijkstraMap([]Pt{IPt(0, 0)})
+	assert.Panics(t, func() { d.NextStep(IPt(-1, 2)) })
+	assert.Panics(t, func() { d.Dist(IPt(1, 3)) })
+	assert.Panics(t, func() { d.Reachable(IPt(4, 0)) })
+}
@@ -0,0 +1,273 @@
+package gamelib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+/*
+Document is a self-describing container for serialized data, such as
+recorded playthroughs.
+
+Serialize writes the raw bytes of a struct, so the bytes don't say what they
+contain. If a struct gets a new field, data written before can no longer be
+read. A Document adds enough information around the data to deal with this:
+- a magic header, so we know the bytes are a Document at all
+- the version of the program that wrote the data
+- named sections, each one prefixed by its length
+
+Programs read a section by name and can skip the sections they don't know
+about. When the layout of a section changes, the program increases its
+version and adds a Migration that converts the sections written by the
+previous version. MigrateDocument runs the migrations needed to bring an old
+Document to the current version.
+
+The layout of the bytes is:
+- documentMagic
+- version: int64
+- number of sections: int64
+- for each section:
+  - length of the name: int64
+  - name
+  - length of the data: int64
+  - data
+
+All numbers are little endian, like in Serialize.
+*/
+type Document struct {
+	Version  int64
+	Sections []Section
+}
+
+type Section struct {
+	Name string
+	Data []byte
+}
+
+var documentMagic = []byte("VLOKDOC\x00")
+
+// Migration converts a Document from one version to the next. It receives
+// the sections written by the old version and must return the sections
+// expected by the new version.
+type Migration func(sections []Section) ([]Section, error)
+
+func NewDocument(version int64) (d Document) {
+	d.Version = version
+	return
+}
+
+// Add appends a section. It crashes if a section with the same name exists.
+func (d *Document) Add(name string, data []byte) {
+	if d.Has(name) {
+		Check(fmt.Errorf("document already has a section called %s", name))
+	}
+	d.Sections = append(d.Sections, Section{name, data})
+}
+
+// Has returns true if the document has a section with this name.
+func (d *Document) Has(name string) bool {
+	return slices.ContainsFunc(d.Sections, func(s Section) bool {
+		return s.Name == name
+	})
+}
+
+// Get returns the data of a section. It crashes if there is no section with
+// this name.
+func (d *Document) Get(name string) []byte {
+	for _, s := range d.Sections {
+		if s.Name == name {
+			return s.Data
+		}
+	}
+	Check(fmt.Errorf("document has no section called %s", name))
+	return nil
+}
+
+func (d *Document) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(documentMagic)
+	Serialize(buf, d.Version)
+	Serialize(buf, int64(len(d.Sections)))
+	for _, s := range d.Sections {
+		Serialize(buf, int64(len(s.Name)))
+		buf.WriteString(s.Name)
+		Serialize(buf, int64(len(s.Data)))
+		buf.Write(s.Data)
+	}
+	return buf.Bytes()
+}
+
+// IsDocument returns true if data starts with the header of a Document.
+func IsDocument(data []byte) bool {
+	return bytes.HasPrefix(data, documentMagic)
+}
+
+// ParseDocument reads a Document written by Document.Bytes. It returns an
+// error instead of crashing, as the data usually comes from a file which may
+// be damaged or may not be a Document at all.
+func ParseDocument(data []byte) (d Document, err error) {
+	r, err := NewDocumentReader(bytes.NewReader(data))
+	if err != nil {
+		return d, err
+	}
+	return r.ReadAll()
+}
+
+// DocumentReader reads a Document written by Document.Bytes from a stream,
+// one section at a time. Unlike ParseDocument, it doesn't need the whole
+// Document in memory, so a large section can be processed while it is being
+// read.
+type DocumentReader struct {
+	// Version of the program that wrote the document.
+	Version   int64
+	r         io.Reader
+	nSections int64
+	nRead     int64
+	// What is left of the current section.
+	section io.Reader
+}
+
+// maxSectionNameLen limits the length of section names, so that damaged data
+// doesn't make us allocate huge amounts of memory.
+const maxSectionNameLen = 1024
+
+// NewDocumentReader reads the header of a Document from r. Like
+// ParseDocument, it returns errors instead of crashing.
+func NewDocumentReader(r io.Reader) (*DocumentReader, error) {
+	magic := make([]byte, len(documentMagic))
+	if _, err := io.ReadFull(r, magic); err != nil ||
+		!bytes.Equal(magic, documentMagic) {
+		return nil, fmt.Errorf("data doesn't start with the document header")
+	}
+
+	d := &DocumentReader{r: r}
+	if err := binary.Read(r, binary.LittleEndian, &d.Version); err != nil {
+		return nil, fmt.Errorf("failed to read document version: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &d.nSections); err != nil {
+		return nil, fmt.Errorf("failed to read number of sections: %w", err)
+	}
+	if d.nSections < 0 {
+		return nil, fmt.Errorf("invalid number of sections: %d", d.nSections)
+	}
+	return d, nil
+}
+
+// Next skips what is left of the current section and starts reading the
+// next one. It returns the name of the section and a reader for its data,
+// which can be used until the next call to Next. After the last section, it
+// returns io.EOF.
+func (d *DocumentReader) Next() (name string, data io.Reader, err error) {
+	if d.section != nil {
+		if _, err = io.Copy(io.Discard, d.section); err != nil {
+			return "", nil, err
+		}
+		d.section = nil
+	}
+	if d.nRead == d.nSections {
+		return "", nil, io.EOF
+	}
+
+	var n int64
+	if err = binary.Read(d.r, binary.LittleEndian, &n); err != nil {
+		return "", nil, fmt.Errorf("failed to read name of section %d: %w",
+			d.nRead, err)
+	}
+	if n < 0 || n > maxSectionNameLen {
+		return "", nil, fmt.Errorf("invalid length %d for name of section %d",
+			n, d.nRead)
+	}
+	nameBytes := make([]byte, n)
+	if _, err = io.ReadFull(d.r, nameBytes); err != nil {
+		return "", nil, fmt.Errorf("failed to read name of section %d: %w",
+			d.nRead, err)
+	}
+	name = string(nameBytes)
+
+	if err = binary.Read(d.r, binary.LittleEndian, &n); err != nil {
+		return "", nil, fmt.Errorf("failed to read section %s: %w", name, err)
+	}
+	if n < 0 {
+		return "", nil, fmt.Errorf("invalid length %d for section %s", n, name)
+	}
+	d.section = &sectionReader{d.r, n}
+	d.nRead++
+	return name, d.section, nil
+}
+
+// ReadAll reads the sections that are left into a Document. Sections are
+// read one at a time, so a damaged length makes it fail when the data runs
+// out instead of allocating memory for all of it.
+func (d *DocumentReader) ReadAll() (Document, error) {
+	var doc Document
+	doc.Version = d.Version
+	for {
+		name, section, err := d.Next()
+		if err == io.EOF {
+			return doc, nil
+		}
+		if err != nil {
+			return doc, err
+		}
+		data, err := io.ReadAll(section)
+		if err != nil {
+			return doc, fmt.Errorf("failed to read section %s: %w", name, err)
+		}
+		doc.Sections = append(doc.Sections, Section{name, data})
+	}
+}
+
+// sectionReader reads the data of a section. Unlike io.LimitReader, it
+// reports io.ErrUnexpectedEOF if the stream ends before the section does.
+type sectionReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (s *sectionReader) Read(p []byte) (n int, err error) {
+	if s.left == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.left {
+		p = p[:s.left]
+	}
+	n, err = s.r.Read(p)
+	s.left -= int64(n)
+	if err == io.EOF {
+		if s.left > 0 {
+			err = io.ErrUnexpectedEOF
+		} else {
+			err = nil
+		}
+	}
+	return
+}
+
+// MigrateDocument brings d to version target. migrations[v] converts a
+// document from version v to version v+1. It returns an error if d is newer
+// than target or if a migration is missing or fails.
+func MigrateDocument(d Document, target int64,
+	migrations map[int64]Migration) (Document, error) {
+	if d.Version > target {
+		return d, fmt.Errorf("document version %d is newer than the "+
+			"supported version %d", d.Version, target)
+	}
+	for d.Version < target {
+		m, ok := migrations[d.Version]
+		if !ok {
+			return d, fmt.Errorf("no migration from version %d to %d",
+				d.Version, d.Version+1)
+		}
+		sections, err := m(d.Sections)
+		if err != nil {
+			return d, fmt.Errorf("migration from version %d to %d failed: %w",
+				d.Version, d.Version+1, err)
+		}
+		d.Sections = sections
+		d.Version++
+	}
+	return d, nil
+}
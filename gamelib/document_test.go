@@ -0,0 +1,130 @@
+package gamelib
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	d := NewDocument(3)
+	d.Add("seed", []byte{1, 2, 3})
+	d.Add("empty", nil)
+	assert.Panics(t, func() { d.Add("seed", nil) })
+
+	data := d.Bytes()
+	assert.True(t, IsDocument(data))
+
+	p, err := ParseDocument(data)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), p.Version)
+	assert.True(t, p.Has("seed"))
+	assert.False(t, p.Has("nothing"))
+	assert.Equal(t, []byte{1, 2, 3}, p.Get("seed"))
+	assert.Empty(t, p.Get("empty"))
+	assert.Panics(t, func() { p.Get("nothing") })
+}
+
+func TestParseDocument_Errors(t *testing.T) {
+	d := NewDocument(1)
+	d.Add("a", []byte("some data"))
+	data := d.Bytes()
+
+	_, err := ParseDocument([]byte("not a document"))
+	assert.NotNil(t, err)
+
+	// Every truncation is detected.
+	for i := 0; i < len(data); i++ {
+		_, err = ParseDocument(data[:i])
+		assert.NotNil(t, err)
+	}
+
+	// A damaged length doesn't make us allocate a lot of memory.
+	damaged := bytes.Clone(data)
+	damaged[len(documentMagic)+16+8+1+7] = 0x7f
+	_, err = ParseDocument(damaged)
+	assert.NotNil(t, err)
+}
+
+func TestDocumentReader(t *testing.T) {
+	d := NewDocument(4)
+	d.Add("skipped", []byte("not needed"))
+	d.Add("partly read", []byte{1, 2, 3, 4})
+	d.Add("read", []byte("some data"))
+
+	r, err := NewDocumentReader(bytes.NewReader(d.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), r.Version)
+
+	name, _, err := r.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "skipped", name)
+
+	name, section, err := r.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "partly read", name)
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(section, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, 2}, buf)
+
+	// The rest of the sections are still there.
+	rest, err := r.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []Section{{"read", []byte("some data")}}, rest.Sections)
+	_, _, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// Reading the data of a truncated section fails.
+	data := d.Bytes()
+	r, err = NewDocumentReader(bytes.NewReader(data[:len(data)-1]))
+	assert.Nil(t, err)
+	_, err = r.ReadAll()
+	assert.NotNil(t, err)
+}
+
+func TestMigrateDocument(t *testing.T) {
+	// Version 1 stored a position, version 2 also stores a speed.
+	type stateV1 struct {
+		Pos Pt
+	}
+	type stateV2 struct {
+		Pos   Pt
+		Speed Int
+	}
+	migrations := map[int64]Migration{
+		1: func(sections []Section) ([]Section, error) {
+			var old stateV1
+			Deserialize(bytes.NewReader(sections[0].Data), &old)
+			buf := new(bytes.Buffer)
+			Serialize(buf, stateV2{old.Pos, I(5)})
+			return []Section{{"state", buf.Bytes()}}, nil
+		},
+	}
+
+	d := NewDocument(1)
+	buf := new(bytes.Buffer)
+	Serialize(buf, stateV1{IPt(3, 4)})
+	d.Add("state", buf.Bytes())
+
+	m, err := MigrateDocument(d, 2, migrations)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), m.Version)
+	var s stateV2
+	Deserialize(bytes.NewReader(m.Get("state")), &s)
+	assert.Equal(t, stateV2{IPt(3, 4), I(5)}, s)
+
+	// Nothing to do.
+	m, err = MigrateDocument(m, 2, migrations)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), m.Version)
+
+	// Missing migration.
+	_, err = MigrateDocument(d, 3, migrations)
+	assert.NotNil(t, err)
+
+	// From the future.
+	_, err = MigrateDocument(m, 1, migrations)
+	assert.NotNil(t, err)
+}
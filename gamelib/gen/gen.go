@@ -0,0 +1,276 @@
+/*
+Package gen generates room layouts.
+
+Every layout is a MatBool where true means the position is blocked. All
+generators make sure the positions that are not blocked form a single
+connected area (using Directions4(), so there's always a path that doesn't cut
+corners). This way, everything placed on a free position can be reached from
+every other free position.
+
+Generators take their random numbers from the Rand they receive, so that a
+level can be re-created from its seed: a Rand created with the same seed always
+gives the same layout. They never use the global random number generator of
+gamelib, so generating a level doesn't change what RInt returns afterwards.
+*/
+package gen
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+)
+
+type CaveParams struct {
+	// Chance, out of 100, that a position starts out blocked.
+	FillPercent Int
+	// How many times to apply the cellular automaton rules.
+	Iterations Int
+	// A free position becomes blocked if at least this many of its 8
+	// neighbors are blocked.
+	BirthLimit Int
+	// A blocked position stays blocked if at least this many of its 8
+	// neighbors are blocked.
+	SurvivalLimit Int
+}
+
+func DefaultCaveParams() (p CaveParams) {
+	p.FillPercent = I(40)
+	p.Iterations = I(4)
+	p.BirthLimit = I(5)
+	p.SurvivalLimit = I(4)
+	return
+}
+
+type BspParams struct {
+	// A region is never split into parts smaller than this, on either axis.
+	MinLeafSize Int
+	// The smallest room that gets carved inside a region, on either axis.
+	MinRoomSize Int
+}
+
+func DefaultBspParams() (p BspParams) {
+	p.MinLeafSize = I(6)
+	p.MinRoomSize = I(3)
+	return
+}
+
+type FurnitureParams struct {
+	// How many pieces of furniture to try to place.
+	Count Int
+	// Size of each piece, in positions.
+	Size Pt
+}
+
+// Room generates a layout for a level. It picks either a cave or a BSP layout
+// and adds some furniture.
+func Room(size Pt, r *Rand) (blocked MatBool) {
+	useCave := r.Int(ZERO, ONE).IsZero()
+	if useCave {
+		blocked = Cave(size, r, DefaultCaveParams())
+	}
+	// Caves can end up with no free positions at all, especially in small
+	// layouts. BSP layouts always have at least one room.
+	if !useCave || blocked.Count().Eq(size.X.Times(size.Y)) {
+		blocked = Bsp(size, r, DefaultBspParams())
+	}
+
+	var f FurnitureParams
+	f.Count = r.Int(I(2), I(5))
+	f.Size = Pt{r.Int(ONE, TWO), r.Int(ONE, TWO)}
+	ScatterFurniture(&blocked, r, f)
+	return
+}
+
+// Cave generates a cave-like layout using a cellular automaton.
+func Cave(size Pt, r *Rand, p CaveParams) (blocked MatBool) {
+	blocked = NewMatBool(size)
+	for y := ZERO; y.Lt(size.Y); y.Inc() {
+		for x := ZERO; x.Lt(size.X); x.Inc() {
+			if r.Int(ZERO, I(99)).Lt(p.FillPercent) {
+				blocked.Set(Pt{x, y})
+			}
+		}
+	}
+
+	dirs := Directions8()
+	for i := ZERO; i.Lt(p.Iterations); i.Inc() {
+		next := NewMatBool(size)
+		for y := ZERO; y.Lt(size.Y); y.Inc() {
+			for x := ZERO; x.Lt(size.X); x.Inc() {
+				pt := Pt{x, y}
+				// Positions outside the layout count as blocked, so that
+				// caves tend to be closed off at the edges.
+				nBlocked := ZERO
+				for _, d := range dirs {
+					n := pt.Plus(d)
+					if !blocked.InBounds(n) || blocked.At(n) {
+						nBlocked.Inc()
+					}
+				}
+				if (blocked.At(pt) && nBlocked.Geq(p.SurvivalLimit)) ||
+					(!blocked.At(pt) && nBlocked.Geq(p.BirthLimit)) {
+					next.Set(pt)
+				}
+			}
+		}
+		blocked = next
+	}
+
+	keepLargestFreeArea(&blocked)
+	return
+}
+
+// Bsp generates a layout of rectangular rooms connected by corridors, by
+// recursively splitting the space in two (binary space partitioning).
+func Bsp(size Pt, r *Rand, p BspParams) (blocked MatBool) {
+	blocked = NewMatBool(size)
+	blocked.SetAll()
+	bspSplit(&blocked, r, Rectangle{Pt{}, size.Minus(Pt{ONE, ONE})}, p)
+	keepLargestFreeArea(&blocked)
+	return
+}
+
+// bspSplit carves rooms inside r (which has inclusive corners) and connects
+// them. It returns a free position inside the carved rooms, which the caller
+// uses to connect r with its sibling.
+func bspSplit(blocked *MatBool, rnd *Rand, r Rectangle, p BspParams) Pt {
+	minPt := r.Min()
+	maxPt := r.Max()
+	// The corners are inclusive, so there is one more position than the size.
+	nPositions := r.Size().Plus(Pt{ONE, ONE})
+	canSplitX := nPositions.X.Geq(p.MinLeafSize.Times(TWO))
+	canSplitY := nPositions.Y.Geq(p.MinLeafSize.Times(TWO))
+
+	if !canSplitX && !canSplitY {
+		return carveRoom(blocked, rnd, r, p)
+	}
+
+	splitX := canSplitX
+	if canSplitX && canSplitY {
+		splitX = rnd.Int(ZERO, ONE).IsZero()
+	}
+
+	var r1, r2 Rectangle
+	if splitX {
+		x := rnd.Int(minPt.X.Plus(p.MinLeafSize), maxPt.X.Minus(p.MinLeafSize).Plus(ONE))
+		r1 = Rectangle{minPt, Pt{x.Minus(ONE), maxPt.Y}}
+		r2 = Rectangle{Pt{x, minPt.Y}, maxPt}
+	} else {
+		y := rnd.Int(minPt.Y.Plus(p.MinLeafSize), maxPt.Y.Minus(p.MinLeafSize).Plus(ONE))
+		r1 = Rectangle{minPt, Pt{maxPt.X, y.Minus(ONE)}}
+		r2 = Rectangle{Pt{minPt.X, y}, maxPt}
+	}
+
+	pt1 := bspSplit(blocked, rnd, r1, p)
+	pt2 := bspSplit(blocked, rnd, r2, p)
+	carveCorridor(blocked, pt1, pt2)
+	return pt1
+}
+
+// carveRoom frees a random rectangle inside r, leaving a margin of one
+// position so that rooms in neighboring regions don't merge.
+func carveRoom(blocked *MatBool, rnd *Rand, r Rectangle, p BspParams) Pt {
+	minPt := r.Min().Plus(Pt{ONE, ONE})
+	maxPt := r.Max().Minus(Pt{ONE, ONE})
+	maxSize := maxPt.Minus(minPt).Plus(Pt{ONE, ONE})
+	minSize := Pt{Min(p.MinRoomSize, maxSize.X), Min(p.MinRoomSize, maxSize.Y)}
+	if minSize.X.IsNonPositive() || minSize.Y.IsNonPositive() {
+		// The region is too small to have a margin, use all of it.
+		minPt, maxPt = r.Min(), r.Max()
+		maxSize = maxPt.Minus(minPt).Plus(Pt{ONE, ONE})
+		minSize = maxSize
+	}
+
+	roomSize := Pt{rnd.Int(minSize.X, maxSize.X), rnd.Int(minSize.Y, maxSize.Y)}
+	corner := Pt{
+		rnd.Int(minPt.X, maxPt.X.Minus(roomSize.X).Plus(ONE)),
+		rnd.Int(minPt.Y, maxPt.Y.Minus(roomSize.Y).Plus(ONE))}
+	for y := ZERO; y.Lt(roomSize.Y); y.Inc() {
+		for x := ZERO; x.Lt(roomSize.X); x.Inc() {
+			blocked.Clear(corner.Plus(Pt{x, y}))
+		}
+	}
+	return corner.Plus(roomSize.DivBy(TWO))
+}
+
+// carveCorridor frees an L-shaped path between two positions.
+func carveCorridor(blocked *MatBool, pt1, pt2 Pt) {
+	pt := pt1
+	blocked.Clear(pt)
+	for pt.X.Neq(pt2.X) {
+		pt.X.Add(step(pt.X, pt2.X))
+		blocked.Clear(pt)
+	}
+	for pt.Y.Neq(pt2.Y) {
+		pt.Y.Add(step(pt.Y, pt2.Y))
+		blocked.Clear(pt)
+	}
+}
+
+// step returns the value to add to from in order to get one step closer to to.
+func step(from, to Int) Int {
+	if from.Lt(to) {
+		return ONE
+	}
+	return ONE.Negative()
+}
+
+// ScatterFurniture blocks rectangles at random free places in blocked. A
+// piece is only placed if the free area stays connected, so it may end up
+// placing fewer pieces than requested. It returns how many were placed.
+func ScatterFurniture(blocked *MatBool, r *Rand, p FurnitureParams) (placed Int) {
+	// Give up after a reasonable number of attempts, as the layout may not
+	// have room for all the pieces.
+	maxAttempts := p.Count.Times(I(10))
+	for attempt := ZERO; attempt.Lt(maxAttempts) && placed.Lt(p.Count); attempt.Inc() {
+		corner, err := blocked.TryRandomUnoccupiedPosWith(r)
+		if err != nil {
+			return
+		}
+
+		piece := NewMatBool(blocked.Size())
+		fits := true
+		for y := ZERO; y.Lt(p.Size.Y) && fits; y.Inc() {
+			for x := ZERO; x.Lt(p.Size.X) && fits; x.Inc() {
+				pt := corner.Plus(Pt{x, y})
+				fits = blocked.InBounds(pt) && !blocked.At(pt)
+				if fits {
+					piece.Set(pt)
+				}
+			}
+		}
+		if !fits {
+			continue
+		}
+
+		candidate := blocked.Clone()
+		candidate.Add(piece)
+		if freeAreaIsConnected(candidate) {
+			*blocked = candidate
+			placed.Inc()
+		}
+	}
+	return
+}
+
+// keepLargestFreeArea blocks all the free positions that are not connected
+// to the largest free area.
+func keepLargestFreeArea(blocked *MatBool) {
+	free := blocked.Clone()
+	free.Negate()
+	labels, regions := free.LabelRegions(Directions4())
+	largest := LargestRegion(regions)
+	for y := ZERO; y.Lt(blocked.Size().Y); y.Inc() {
+		for x := ZERO; x.Lt(blocked.Size().X); x.Inc() {
+			pt := Pt{x, y}
+			if labels.Get(pt) >= 0 && labels.Get(pt) != largest {
+				blocked.Set(pt)
+			}
+		}
+	}
+}
+
+func freeAreaIsConnected(blocked MatBool) bool {
+	free := blocked.Clone()
+	free.Negate()
+	_, regions := free.LabelRegions(Directions4())
+	return len(regions) <= 1
+}
@@ -0,0 +1,86 @@
+package gen
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func assertSingleFreeArea(t *testing.T, blocked MatBool) {
+	free := blocked.Clone()
+	free.Negate()
+	_, regions := free.LabelRegions(Directions4())
+	assert.Equal(t, 1, len(regions))
+}
+
+func TestCave(t *testing.T) {
+	size := IPt(40, 30)
+	for seed := 0; seed < 20; seed++ {
+		blocked := Cave(size, NewRand(I(seed)), DefaultCaveParams())
+		assert.Equal(t, size, blocked.Size())
+		assertSingleFreeArea(t, blocked)
+		assert.Equal(t, blocked, Cave(size, NewRand(I(seed)), DefaultCaveParams()))
+	}
+}
+
+func TestBsp(t *testing.T) {
+	size := IPt(40, 30)
+	for seed := 0; seed < 20; seed++ {
+		blocked := Bsp(size, NewRand(I(seed)), DefaultBspParams())
+		assert.Equal(t, size, blocked.Size())
+		assertSingleFreeArea(t, blocked)
+		assert.Equal(t, blocked, Bsp(size, NewRand(I(seed)), DefaultBspParams()))
+	}
+
+	// A layout too small to split is a single room.
+	blocked := Bsp(IPt(5, 5), NewRand(ZERO), DefaultBspParams())
+	assertSingleFreeArea(t, blocked)
+}
+
+func TestScatterFurniture(t *testing.T) {
+	// Furniture in the middle of a corridor would cut it in two, so it never
+	// gets placed there.
+	blocked := MatBoolFromMatrix(MatrixFromString(`
+xxxxxxxxxx
+x--------x
+xxxxxxxxxx
+`, map[byte]bool{'x': true}))
+	var p FurnitureParams
+	p.Count = I(3)
+	p.Size = IPt(1, 1)
+	placed := ScatterFurniture(&blocked, NewRand(ZERO), p)
+	assertSingleFreeArea(t, blocked)
+	assert.Equal(t, I(22).Plus(placed), blocked.Count())
+	for x := 2; x <= 7; x++ {
+		if blocked.At(IPt(x, 1)) {
+			assert.True(t, blocked.At(IPt(x-1, 1)) || blocked.At(IPt(x+1, 1)))
+		}
+	}
+
+	open := NewMatBool(IPt(20, 20))
+	p.Count = I(5)
+	p.Size = IPt(2, 3)
+	placed = ScatterFurniture(&open, NewRand(I(3)), p)
+	assert.Equal(t, I(5), placed)
+	assert.Equal(t, I(5*6), open.Count())
+	assertSingleFreeArea(t, open)
+}
+
+func TestRoom(t *testing.T) {
+	size := IPt(18, 18)
+	for seed := 0; seed < 50; seed++ {
+		blocked := Room(size, NewRand(I(seed)))
+		assertSingleFreeArea(t, blocked)
+		assert.Equal(t, blocked, Room(size, NewRand(I(seed))))
+	}
+	assert.NotEqual(t, Room(size, NewRand(I(1))), Room(size, NewRand(I(2))))
+}
+
+func TestRoom_KeepsGlobalRandom(t *testing.T) {
+	RSeed(I(7))
+	expected := RInt(ZERO, I(1000000))
+
+	RSeed(I(7))
+	Room(IPt(18, 18), NewRand(I(3)))
+	assert.Equal(t, expected, RInt(ZERO, I(1000000)))
+}
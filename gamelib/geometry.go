@@ -42,6 +42,196 @@ func (r *Rectangle) ContainsPt(pt Pt) bool {
 	return pt.X.Geq(minX) && pt.X.Leq(maxX) && pt.Y.Geq(minY) && pt.Y.Leq(maxY)
 }
 
+// Intersect returns the rectangle covered by both r and other. Rectangles
+// include their edges, so rectangles that only touch intersect in a
+// rectangle with a width or height of 0.
+func (r *Rectangle) Intersect(other Rectangle) (bool, Rectangle) {
+	minPt := Pt{Max(r.Min().X, other.Min().X), Max(r.Min().Y, other.Min().Y)}
+	maxPt := Pt{Min(r.Max().X, other.Max().X), Min(r.Max().Y, other.Max().Y)}
+	if minPt.X.Gt(maxPt.X) || minPt.Y.Gt(maxPt.Y) {
+		return false, Rectangle{}
+	}
+	return true, Rectangle{minPt, maxPt}
+}
+
+// Union returns the smallest rectangle that contains both r and other.
+func (r *Rectangle) Union(other Rectangle) Rectangle {
+	minPt := Pt{Min(r.Min().X, other.Min().X), Min(r.Min().Y, other.Min().Y)}
+	maxPt := Pt{Max(r.Max().X, other.Max().X), Max(r.Max().Y, other.Max().Y)}
+	return Rectangle{minPt, maxPt}
+}
+
+// RectangleFromCenterSize returns the rectangle of the given size centered at
+// center. If size is odd, the extra unit goes to the right and bottom.
+func RectangleFromCenterSize(center Pt, size Pt) Rectangle {
+	minPt := center.Minus(size.DivBy(TWO))
+	return Rectangle{minPt, minPt.Plus(size)}
+}
+
+func (r *Rectangle) Size() Pt {
+	return Pt{r.Width(), r.Height()}
+}
+
+func (r *Rectangle) Area() Int {
+	return r.Width().Times(r.Height())
+}
+
+func (r *Rectangle) Center() Pt {
+	return r.Min().Plus(r.Max()).DivBy(TWO)
+}
+
+// Overlaps returns true if r and other have at least one point in common.
+func (r *Rectangle) Overlaps(other Rectangle) bool {
+	ok, _ := r.Intersect(other)
+	return ok
+}
+
+// ContainsRect returns true if all of other is inside r.
+func (r *Rectangle) ContainsRect(other Rectangle) bool {
+	return r.ContainsPt(other.Min()) && r.ContainsPt(other.Max())
+}
+
+// Clamp returns the point inside r that is closest to pt.
+func (r *Rectangle) Clamp(pt Pt) Pt {
+	minPt, maxPt := r.Min(), r.Max()
+	return Pt{Max(minPt.X, Min(pt.X, maxPt.X)), Max(minPt.Y, Min(pt.Y, maxPt.Y))}
+}
+
+// ClosestPt implements Area, it's the same as Clamp.
+func (r *Rectangle) ClosestPt(pt Pt) Pt {
+	return r.Clamp(pt)
+}
+
+// Expand returns r grown by amount on every side. A negative amount shrinks
+// it, but not beyond its center.
+func (r *Rectangle) Expand(amount Int) Rectangle {
+	minPt := r.Min().Minus(Pt{amount, amount})
+	maxPt := r.Max().Plus(Pt{amount, amount})
+	center := r.Center()
+	if minPt.X.Gt(maxPt.X) {
+		minPt.X, maxPt.X = center.X, center.X
+	}
+	if minPt.Y.Gt(maxPt.Y) {
+		minPt.Y, maxPt.Y = center.Y, center.Y
+	}
+	return Rectangle{minPt, maxPt}
+}
+
+// Translate returns r moved by offset.
+func (r *Rectangle) Translate(offset Pt) Rectangle {
+	return Rectangle{r.Corner1.Plus(offset), r.Corner2.Plus(offset)}
+}
+
+func (c Circle) Radius() Int {
+	return c.Diameter.DivBy(TWO)
+}
+
+func (c Circle) ContainsPt(pt Pt) bool {
+	return c.Center.SquaredDistTo(pt).Leq(c.Radius().Sqr())
+}
+
+// ClosestPt returns the point inside c that is closest to pt.
+func (c Circle) ClosestPt(pt Pt) Pt {
+	if c.ContainsPt(pt) {
+		return pt
+	}
+	dir := c.Center.To(pt)
+	dir.SetLen(c.Radius())
+	// Rounding may leave the point just outside the circle.
+	for !c.ContainsPt(c.Center.Plus(dir)) {
+		dir.AddLen(I(-1))
+	}
+	return c.Center.Plus(dir)
+}
+
+// CirclesOverlap returns true if the circles have at least one point in
+// common.
+func CirclesOverlap(c1, c2 Circle) bool {
+	radii := c1.Radius().Plus(c2.Radius())
+	return c1.Center.SquaredDistTo(c2.Center).Leq(radii.Sqr())
+}
+
+// CircleRectangleOverlap returns true if the circle and the rectangle have at
+// least one point in common.
+func CircleRectangleOverlap(c Circle, r Rectangle) bool {
+	// Check the point of the rectangle closest to the center of the circle.
+	return c.ContainsPt(r.Clamp(c.Center))
+}
+
+// ClosestPointOnLine returns the point on l that is closest to pt.
+func ClosestPointOnLine(l Line, pt Pt) Pt {
+	dir := l.Start.To(l.End)
+	lenSqr := dir.SquaredLen()
+	if lenSqr.IsZero() {
+		return l.Start
+	}
+
+	// The projection of pt on the line is l.Start + dir * t / lenSqr.
+	// Keep it inside the segment.
+	t := l.Start.To(pt).Dot(dir)
+	t = Max(ZERO, Min(t, lenSqr))
+	return l.Start.Plus(dir.Times(t).DivBy(lenSqr))
+}
+
+// DistToLine returns the distance from p to the closest point on l.
+func (p Pt) DistToLine(l Line) Int {
+	return p.DistTo(ClosestPointOnLine(l, p))
+}
+
+// LineLineIntersection returns the point where l1 and l2 intersect. If they
+// overlap along a common segment, it returns the point of that segment
+// closest to l1.Start.
+func LineLineIntersection(l1, l2 Line) (bool, Pt) {
+	// Degenerate lines are points.
+	if l1.Start == l1.End {
+		return ClosestPointOnLine(l2, l1.Start) == l1.Start, l1.Start
+	}
+	if l2.Start == l2.End {
+		return ClosestPointOnLine(l1, l2.Start) == l2.Start, l2.Start
+	}
+
+	// l1 is p + r*t and l2 is q + s*u, with t and u in [0, 1].
+	// The intersection is at:
+	// t = (q - p) x s / (r x s)
+	// u = (q - p) x r / (r x s)
+	// To avoid losing precision, keep t and u as fractions of the
+	// denominator.
+	r := l1.Start.To(l1.End)
+	s := l2.Start.To(l2.End)
+	qp := l1.Start.To(l2.Start)
+	denom := r.Cross(s)
+	tNum := qp.Cross(s)
+	uNum := qp.Cross(r)
+
+	if denom.IsZero() {
+		if !uNum.IsZero() {
+			// Parallel, on different lines.
+			return false, Pt{}
+		}
+
+		// On the same line. Project l2 on l1 to see where they overlap.
+		rr := r.SquaredLen()
+		t0 := qp.Dot(r)
+		t1 := l1.Start.To(l2.End).Dot(r)
+		t0, t1 = MinMax(t0, t1)
+		if t1.IsNegative() || t0.Gt(rr) {
+			return false, Pt{}
+		}
+		t := Max(ZERO, t0)
+		return true, l1.Start.Plus(r.Times(t).DivBy(rr))
+	}
+
+	if denom.IsNegative() {
+		denom = denom.Negative()
+		tNum = tNum.Negative()
+		uNum = uNum.Negative()
+	}
+	if tNum.IsNegative() || tNum.Gt(denom) || uNum.IsNegative() || uNum.Gt(denom) {
+		return false, Pt{}
+	}
+	return true, l1.Start.Plus(r.Times(tNum).DivBy(denom))
+}
+
 func LineVerticalLineIntersection(l, vert Line) (bool, Pt) {
 	// Check if the Lines even intersect.
 
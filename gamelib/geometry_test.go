@@ -0,0 +1,124 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLineLineIntersection(t *testing.T) {
+	// Crossing.
+	ok, pt := LineLineIntersection(Line{IPt(0, 0), IPt(10, 10)}, Line{IPt(0, 10), IPt(10, 0)})
+	assert.True(t, ok)
+	assert.Equal(t, IPt(5, 5), pt)
+
+	// Touching at an end.
+	ok, pt = LineLineIntersection(Line{IPt(0, 0), IPt(10, 0)}, Line{IPt(10, 0), IPt(10, 10)})
+	assert.True(t, ok)
+	assert.Equal(t, IPt(10, 0), pt)
+
+	// Would cross if they were longer.
+	ok, _ = LineLineIntersection(Line{IPt(0, 0), IPt(4, 4)}, Line{IPt(0, 10), IPt(10, 0)})
+	assert.False(t, ok)
+
+	// Parallel.
+	ok, _ = LineLineIntersection(Line{IPt(0, 0), IPt(10, 0)}, Line{IPt(0, 1), IPt(10, 1)})
+	assert.False(t, ok)
+
+	// Overlapping on the same line, in both orders.
+	ok, pt = LineLineIntersection(Line{IPt(0, 0), IPt(10, 0)}, Line{IPt(15, 0), IPt(5, 0)})
+	assert.True(t, ok)
+	assert.Equal(t, IPt(5, 0), pt)
+	ok, pt = LineLineIntersection(Line{IPt(10, 0), IPt(0, 0)}, Line{IPt(15, 0), IPt(5, 0)})
+	assert.True(t, ok)
+	assert.Equal(t, IPt(10, 0), pt)
+
+	// On the same line, but not overlapping.
+	ok, _ = LineLineIntersection(Line{IPt(0, 0), IPt(10, 0)}, Line{IPt(11, 0), IPt(20, 0)})
+	assert.False(t, ok)
+
+	// Points.
+	ok, pt = LineLineIntersection(Line{IPt(3, 3), IPt(3, 3)}, Line{IPt(0, 0), IPt(6, 6)})
+	assert.True(t, ok)
+	assert.Equal(t, IPt(3, 3), pt)
+	ok, _ = LineLineIntersection(Line{IPt(0, 0), IPt(6, 6)}, Line{IPt(3, 4), IPt(3, 4)})
+	assert.False(t, ok)
+
+	// World-sized coordinates don't overflow.
+	ok, pt = LineLineIntersection(Line{UPt(0, 0), UPt(900, 900)}, Line{UPt(0, 900), UPt(900, 0)})
+	assert.True(t, ok)
+	assert.Equal(t, UPt(450, 450), pt)
+}
+
+func TestClosestPointOnLine(t *testing.T) {
+	l := Line{IPt(0, 0), IPt(10, 0)}
+	assert.Equal(t, IPt(4, 0), ClosestPointOnLine(l, IPt(4, 7)))
+	assert.Equal(t, IPt(0, 0), ClosestPointOnLine(l, IPt(-4, 7)))
+	assert.Equal(t, IPt(10, 0), ClosestPointOnLine(l, IPt(14, -7)))
+	assert.Equal(t, I(7), IPt(4, 7).DistToLine(l))
+	assert.Equal(t, I(5), IPt(13, 4).DistToLine(l))
+
+	point := Line{IPt(2, 2), IPt(2, 2)}
+	assert.Equal(t, IPt(2, 2), ClosestPointOnLine(point, IPt(5, 6)))
+	assert.Equal(t, I(5), IPt(5, 6).DistToLine(point))
+}
+
+func TestCirclesOverlap(t *testing.T) {
+	c1 := Circle{IPt(0, 0), I(10)}
+	assert.True(t, CirclesOverlap(c1, Circle{IPt(8, 0), I(6)}))
+	assert.False(t, CirclesOverlap(c1, Circle{IPt(9, 0), I(6)}))
+	assert.True(t, CirclesOverlap(c1, Circle{IPt(6, 8), I(10)}))
+	assert.False(t, CirclesOverlap(c1, Circle{IPt(6, 8), I(8)}))
+}
+
+func TestCircleRectangleOverlap(t *testing.T) {
+	r := Rectangle{IPt(0, 0), IPt(10, 10)}
+	assert.True(t, CircleRectangleOverlap(Circle{IPt(5, 5), I(2)}, r))
+	assert.True(t, CircleRectangleOverlap(Circle{IPt(13, 5), I(6)}, r))
+	assert.False(t, CircleRectangleOverlap(Circle{IPt(14, 5), I(6)}, r))
+	// Close to a corner, on the diagonal.
+	assert.True(t, CircleRectangleOverlap(Circle{IPt(13, 14), I(10)}, r))
+	assert.False(t, CircleRectangleOverlap(Circle{IPt(14, 14), I(10)}, r))
+}
+
+func TestRectangle_IntersectUnion(t *testing.T) {
+	r1 := Rectangle{IPt(0, 10), IPt(10, 0)}
+	r2 := Rectangle{IPt(5, 5), IPt(20, 15)}
+	ok, r := r1.Intersect(r2)
+	assert.True(t, ok)
+	assert.Equal(t, Rectangle{IPt(5, 5), IPt(10, 10)}, r)
+	assert.Equal(t, Rectangle{IPt(0, 0), IPt(20, 15)}, r1.Union(r2))
+
+	ok, r = r1.Intersect(Rectangle{IPt(10, 0), IPt(12, 3)})
+	assert.True(t, ok)
+	assert.Equal(t, I(0), r.Width())
+
+	ok, _ = r1.Intersect(Rectangle{IPt(11, 0), IPt(12, 3)})
+	assert.False(t, ok)
+}
+
+func TestRectangle_Helpers(t *testing.T) {
+	r := Rectangle{IPt(10, 20), IPt(0, 0)}
+	assert.Equal(t, IPt(10, 20), r.Size())
+	assert.Equal(t, I(200), r.Area())
+	assert.Equal(t, IPt(5, 10), r.Center())
+	assert.Equal(t, Rectangle{IPt(15, 25), IPt(5, 5)}, r.Translate(IPt(5, 5)))
+
+	assert.Equal(t, IPt(10, 5), r.Clamp(IPt(30, 5)))
+	assert.Equal(t, IPt(0, 20), r.Clamp(IPt(-3, 40)))
+	assert.Equal(t, IPt(3, 4), r.Clamp(IPt(3, 4)))
+
+	assert.Equal(t, Rectangle{IPt(-2, -2), IPt(12, 22)}, r.Expand(I(2)))
+	assert.Equal(t, Rectangle{IPt(5, 5), IPt(5, 15)}, r.Expand(I(-5)))
+	assert.Equal(t, Rectangle{IPt(5, 10), IPt(5, 10)}, r.Expand(I(-50)))
+
+	c := RectangleFromCenterSize(IPt(5, 5), IPt(4, 6))
+	assert.Equal(t, Rectangle{IPt(3, 2), IPt(7, 8)}, c)
+	assert.Equal(t, IPt(4, 6), c.Size())
+	assert.Equal(t, IPt(5, 5), c.Center())
+
+	assert.True(t, r.Overlaps(c))
+	assert.True(t, r.Overlaps(Rectangle{IPt(10, 20), IPt(30, 30)}))
+	assert.False(t, r.Overlaps(Rectangle{IPt(11, 20), IPt(30, 30)}))
+	assert.True(t, r.ContainsRect(c))
+	assert.False(t, c.ContainsRect(r))
+}
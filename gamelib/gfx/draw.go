@@ -1,7 +1,8 @@
-package gamelib
+package gfx
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
+	. "github.com/marisvali/vlok/gamelib"
 	"image"
 	"image/color"
 )
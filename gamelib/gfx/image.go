@@ -0,0 +1,53 @@
+package gfx
+
+import (
+	"embed"
+	"github.com/hajimehoshi/ebiten/v2"
+	. "github.com/marisvali/vlok/gamelib"
+	"image"
+	"image/color"
+	"io/fs"
+	"os"
+)
+
+func LoadImage(str string) *ebiten.Image {
+	file, err := os.Open(str)
+	defer func(file *os.File) { Check(file.Close()) }(file)
+	Check(err)
+
+	img, _, err := image.Decode(file)
+	Check(err)
+	if err != nil {
+		return nil
+	}
+
+	return ebiten.NewImageFromImage(img)
+}
+
+func LoadImageEmbedded(str string, efs *embed.FS) *ebiten.Image {
+	file, err := efs.Open(str)
+	defer func(file fs.File) { Check(file.Close()) }(file)
+	Check(err)
+
+	img, _, err := image.Decode(file)
+	Check(err)
+	if err != nil {
+		return nil
+	}
+
+	return ebiten.NewImageFromImage(img)
+}
+
+func ComputeSpriteMask(img *ebiten.Image) *ebiten.Image {
+	mask := ebiten.NewImageFromImage(img)
+	sz := mask.Bounds().Size()
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			_, _, _, a := img.At(x, y).RGBA()
+			if a > 0 {
+				mask.Set(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	return mask
+}
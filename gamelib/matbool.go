@@ -1,116 +1,328 @@
 package gamelib
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/bits"
 	"slices"
 )
 
+// MatBool is a matrix of bools, which is mostly used as a set of positions.
+// The values are packed as bits in uint64 words, so that set operations can
+// process 64 positions at once. The bits beyond the last position are always
+// 0, so that words can be compared and counted directly.
 type MatBool struct {
-	Matrix[bool]
+	words []uint64
+	size  Pt
 }
 
+const wordBits = 64
+
 func NewMatBool(size Pt) (m MatBool) {
 	m.size = size
-	m.cells = make([]bool, size.Y.Times(size.X).ToInt64())
+	nCells := size.Y.Times(size.X).ToInt()
+	m.words = make([]uint64, (nCells+wordBits-1)/wordBits)
 	return m
 }
 
+func MatBoolFromMatrix(other Matrix[bool]) (m MatBool) {
+	m = NewMatBool(other.Size())
+	for i := range other.cells {
+		if other.cells[i] {
+			m.words[i/wordBits] |= 1 << (i % wordBits)
+		}
+	}
+	return
+}
+
+func (m *MatBool) ToMatrix() (other Matrix[bool]) {
+	other = NewMatrix[bool](m.size)
+	m.forEachIndex(func(i int) {
+		other.cells[i] = true
+	})
+	return
+}
+
+// MarshalJSON writes m as a list of rows, with 'x' for true and '-' for
+// false, so that it's readable in crash reports.
+func (m *MatBool) MarshalJSON() ([]byte, error) {
+	var rows []string
+	for y := ZERO; y.Lt(m.size.Y); y.Inc() {
+		row := make([]byte, 0, m.size.X.ToInt())
+		for x := ZERO; x.Lt(m.size.X); x.Inc() {
+			if m.Get(Pt{x, y}) {
+				row = append(row, 'x')
+			} else {
+				row = append(row, '-')
+			}
+		}
+		rows = append(rows, string(row))
+	}
+	return json.Marshal(rows)
+}
+
+// UnmarshalJSON reads the rows written by MarshalJSON.
+func (m *MatBool) UnmarshalJSON(data []byte) error {
+	var rows []string
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return err
+	}
+	width := 0
+	if len(rows) > 0 {
+		width = len(rows[0])
+	}
+	*m = NewMatBool(IPt(width, len(rows)))
+	for y, row := range rows {
+		if len(row) != width {
+			return fmt.Errorf("row %d has %d positions instead of %d", y,
+				len(row), width)
+		}
+		for x := range row {
+			if row[x] == 'x' {
+				m.Set(IPt(x, y))
+			}
+		}
+	}
+	return nil
+}
+
+// checkInBounds crashes if pos is outside the matrix. Positions past the end
+// of a row would otherwise silently wrap to the next row, or land in the
+// padding of the last word.
+func (m *MatBool) checkInBounds(pos Pt) {
+	if !m.InBounds(pos) {
+		Check(fmt.Errorf("position (%d, %d) is outside matrix of size "+
+			"(%d, %d)", pos.X.ToInt(), pos.Y.ToInt(), m.size.X.ToInt(),
+			m.size.Y.ToInt()))
+	}
+}
+
 func (m *MatBool) Clone() (c MatBool) {
 	c.size = m.size
-	c.cells = append(c.cells, m.cells...)
+	c.words = append(c.words, m.words...)
+	return
+}
+
+func (m *MatBool) Size() Pt {
+	return m.size
+}
+
+func (m *MatBool) InBounds(pt Pt) bool {
+	return pt.X.IsNonNegative() &&
+		pt.Y.IsNonNegative() &&
+		pt.Y.Lt(m.size.Y) &&
+		pt.X.Lt(m.size.X)
+}
+
+func (m *MatBool) PtToIndex(p Pt) Int {
+	return p.Y.Times(m.size.X).Plus(p.X)
+}
+
+func (m *MatBool) IndexToPt(i Int) (p Pt) {
+	p.X = i.Mod(m.size.X)
+	p.Y = i.DivBy(m.size.X)
 	return
 }
 
+func (m *MatBool) RandomPos() Pt {
+	var pt Pt
+	pt.X = RInt(ZERO, m.Size().X.Minus(ONE))
+	pt.Y = RInt(ZERO, m.Size().Y.Minus(ONE))
+	return pt
+}
+
+func (m *MatBool) Get(pos Pt) bool {
+	m.checkInBounds(pos)
+	i := m.PtToIndex(pos).ToInt()
+	return m.words[i/wordBits]&(1<<(i%wordBits)) != 0
+}
+
 func (m *MatBool) At(pos Pt) bool {
 	return m.Get(pos)
 }
 
 func (m *MatBool) Set(pos Pt) {
-	m.Matrix.Set(pos, true)
+	m.checkInBounds(pos)
+	i := m.PtToIndex(pos).ToInt()
+	m.words[i/wordBits] |= 1 << (i % wordBits)
 }
 
 func (m *MatBool) SetAll() {
-	for i := range m.cells {
-		m.cells[i] = true
+	for i := range m.words {
+		m.words[i] = ^uint64(0)
 	}
+	m.clearPadding()
 }
 
 func (m *MatBool) Clear(pos Pt) {
-	m.Matrix.Set(pos, false)
+	m.checkInBounds(pos)
+	i := m.PtToIndex(pos).ToInt()
+	m.words[i/wordBits] &^= 1 << (i % wordBits)
 }
 
 func (m *MatBool) ClearAll() {
-	for i := range m.cells {
-		m.cells[i] = false
+	for i := range m.words {
+		m.words[i] = 0
 	}
 }
 
-// Add performs the union operation between the two sets represented by the
-// matrices.
-func (m *MatBool) Add(other MatBool) {
+// clearPadding sets to 0 the bits of the last word that don't correspond to
+// any position.
+func (m *MatBool) clearPadding() {
+	nCells := m.size.Y.Times(m.size.X).ToInt()
+	if nCells%wordBits != 0 {
+		m.words[len(m.words)-1] &= (1 << (nCells % wordBits)) - 1
+	}
+}
+
+func (m *MatBool) checkSameSize(other MatBool) {
 	if m.size != other.size {
 		Check(fmt.Errorf("trying to combine matrices of different sizes: "+
 			"(%d, %d) and (%d, %d)", m.size.X.ToInt(), m.size.Y.ToInt(),
 			other.size.X.ToInt(), other.size.Y.ToInt()))
 	}
+}
 
-	for i := range m.cells {
-		m.cells[i] = m.cells[i] || other.cells[i]
+// Add performs the union operation between the two sets represented by the
+// matrices.
+func (m *MatBool) Add(other MatBool) {
+	m.checkSameSize(other)
+	for i := range m.words {
+		m.words[i] |= other.words[i]
 	}
 }
 
 // Subtract performs the subtraction operation between the two sets represented
 // by the matrices. As in, what's true in other becomes false in m.
 func (m *MatBool) Subtract(other MatBool) {
-	if m.size != other.size {
-		Check(fmt.Errorf("trying to combine matrices of different sizes: "+
-			"(%d, %d) and (%d, %d)", m.size.X.ToInt(), m.size.Y.ToInt(),
-			other.size.X.ToInt(), other.size.Y.ToInt()))
-	}
-
-	for i := range m.cells {
-		m.cells[i] = m.cells[i] && !other.cells[i]
+	m.checkSameSize(other)
+	for i := range m.words {
+		m.words[i] &^= other.words[i]
 	}
 }
 
 // IntersectWith performs the intersection operation between the two sets
 // represented by the matrices.
 func (m *MatBool) IntersectWith(other MatBool) {
-	if m.size != other.size {
-		Check(fmt.Errorf("trying to combine matrices of different sizes: "+
-			"(%d, %d) and (%d, %d)", m.size.X.ToInt(), m.size.Y.ToInt(),
-			other.size.X.ToInt(), other.size.Y.ToInt()))
-	}
-
-	for i := range m.cells {
-		m.cells[i] = m.cells[i] && other.cells[i]
+	m.checkSameSize(other)
+	for i := range m.words {
+		m.words[i] &= other.words[i]
 	}
 }
 
 // Negate changes the matrix so that each position has the opposite value (true
 // becomes false, false becomes true).
 func (m *MatBool) Negate() {
-	for i := range m.cells {
-		m.cells[i] = !m.cells[i]
+	for i := range m.words {
+		m.words[i] = ^m.words[i]
 	}
-	return
+	m.clearPadding()
+}
+
+// Count returns the number of positions that are true.
+func (m *MatBool) Count() Int {
+	n := 0
+	for _, w := range m.words {
+		n += bits.OnesCount64(w)
+	}
+	return I(n)
+}
+
+// ForEach calls f for every position that is true, in increasing order of
+// their indexes (row by row).
+func (m *MatBool) ForEach(f func(pt Pt)) {
+	m.forEachIndex(func(i int) {
+		f(m.IndexToPt(I(i)))
+	})
 }
 
+// forEachIndex calls f for the index of every position that is true. It only
+// looks at the bits that are set, so it is fast for sparse matrices.
+func (m *MatBool) forEachIndex(f func(i int)) {
+	for iWord, w := range m.words {
+		for w != 0 {
+			iBit := bits.TrailingZeros64(w)
+			f(iWord*wordBits + iBit)
+			w &= w - 1 // Clear the lowest bit that is set.
+		}
+	}
+}
+
+// RandomUnoccupiedPos returns a random position that is false. It crashes if
+// all positions are true.
 func (m MatBool) RandomUnoccupiedPos() (p Pt) {
-	for {
-		p = m.RandomPos()
-		if !m.Get(p) {
-			return
+	p, err := m.TryRandomUnoccupiedPos()
+	Check(err)
+	return
+}
+
+// TryRandomUnoccupiedPos returns a random position that is false. All such
+// positions have the same chance of being chosen. It returns an error if all
+// positions are true.
+func (m *MatBool) TryRandomUnoccupiedPos() (Pt, error) {
+	return m.TryRandomUnoccupiedPosWith(randomGenerator)
+}
+
+// TryRandomUnoccupiedPosWith is like TryRandomUnoccupiedPos, but takes the
+// random numbers from r instead of the global generator.
+func (m *MatBool) TryRandomUnoccupiedPosWith(r *Rand) (Pt, error) {
+	nCells := m.size.X.Times(m.size.Y)
+	nFree := nCells.Minus(m.Count())
+	if nFree.IsZero() {
+		return Pt{}, fmt.Errorf("no unoccupied position in matrix of size "+
+			"(%d, %d)", m.size.X.ToInt(), m.size.Y.ToInt())
+	}
+
+	// Pick which free position we want and then go look for it. Skip whole
+	// words by counting their free positions, so this is fast even when only
+	// a few positions are free.
+	k := r.Int(ZERO, nFree.Minus(ONE)).ToInt()
+	for iWord, w := range m.words {
+		free := ^w
+		if iWord == len(m.words)-1 && nCells.ToInt()%wordBits != 0 {
+			free &= (1 << (nCells.ToInt() % wordBits)) - 1
 		}
+		n := bits.OnesCount64(free)
+		if k >= n {
+			k -= n
+			continue
+		}
+		for ; k > 0; k-- {
+			free &= free - 1 // Clear the lowest bit that is set.
+		}
+		i := iWord*wordBits + bits.TrailingZeros64(free)
+		return m.IndexToPt(I(i)), nil
 	}
+	panic("unreachable: free positions were counted but not found")
 }
 
+// OccupyRandomPos sets a random position that is false and returns it. It
+// crashes if all positions are true.
 func (m *MatBool) OccupyRandomPos() (p Pt) {
 	p = m.RandomUnoccupiedPos()
 	m.Set(p)
 	return
 }
 
+// OccupyRandomPositions sets n random positions that are false and returns
+// them. If there are fewer than n positions that are false, it returns an
+// error and doesn't change the matrix.
+func (m *MatBool) OccupyRandomPositions(n Int) (pts []Pt, err error) {
+	nFree := m.size.X.Times(m.size.Y).Minus(m.Count())
+	if nFree.Lt(n) {
+		return nil, fmt.Errorf("cannot occupy %d positions, only %d are "+
+			"unoccupied", n.ToInt(), nFree.ToInt())
+	}
+
+	for i := ZERO; i.Lt(n); i.Inc() {
+		p, err := m.TryRandomUnoccupiedPos()
+		Check(err) // We checked that enough positions are free.
+		m.Set(p)
+		pts = append(pts, p)
+	}
+	return
+}
+
 // ConnectedPositions returns a bool matrix that shows all the positions
 // connected to the start point. A position is connected if there is a path
 // between it and the start point, where all the elements of the path have the
@@ -138,12 +350,9 @@ func (m MatBool) ConnectedPositions(start Pt) (res MatBool) {
 }
 
 func (m MatBool) ToSlice() (s []Pt) {
-	nCols := m.size.X.ToInt()
-	for i := range m.cells {
-		if m.cells[i] {
-			s = append(s, IPt(i%nCols, i/nCols))
-		}
-	}
+	m.ForEach(func(pt Pt) {
+		s = append(s, pt)
+	})
 	return
 }
 
@@ -154,5 +363,5 @@ func (m *MatBool) FromSlice(s []Pt) {
 }
 
 func (m *MatBool) Equal(o MatBool) bool {
-	return m.size == o.size && slices.Equal(m.cells, o.cells)
+	return m.size == o.size && slices.Equal(m.words, o.words)
 }
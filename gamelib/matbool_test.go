@@ -1,6 +1,7 @@
 package gamelib
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -43,3 +44,133 @@ func Test_Intersect(t *testing.T) {
 
 	assert.Equal(t, result, expected)
 }
+
+func Test_Subtract(t *testing.T) {
+	result := NewMatBool(IPt(4, 4))
+	result.Set(IPt(1, 2))
+	result.Set(IPt(3, 3))
+
+	m2 := NewMatBool(IPt(4, 4))
+	m2.Set(IPt(1, 2))
+	m2.Set(IPt(0, 0))
+
+	expected := NewMatBool(IPt(4, 4))
+	expected.Set(IPt(3, 3))
+
+	result.Subtract(m2)
+
+	assert.Equal(t, result, expected)
+}
+
+func Test_Negate(t *testing.T) {
+	// Use a size that doesn't fill the last word, to check that the unused
+	// bits don't become set.
+	m := NewMatBool(IPt(9, 9))
+	m.Set(IPt(8, 8))
+	m.Negate()
+	assert.Equal(t, I(80), m.Count())
+	assert.False(t, m.At(IPt(8, 8)))
+	m.Negate()
+	assert.Equal(t, ONE, m.Count())
+
+	m.SetAll()
+	assert.Equal(t, I(81), m.Count())
+}
+
+func Test_CountAndForEach(t *testing.T) {
+	m := NewMatBool(IPt(100, 3))
+	pts := []Pt{IPt(5, 0), IPt(63, 0), IPt(64, 0), IPt(99, 1), IPt(0, 2), IPt(99, 2)}
+	m.FromSlice(pts)
+	assert.Equal(t, I(len(pts)), m.Count())
+	assert.Equal(t, pts, m.ToSlice())
+
+	m.Clear(IPt(64, 0))
+	assert.Equal(t, I(len(pts)-1), m.Count())
+	assert.False(t, m.At(IPt(64, 0)))
+	assert.True(t, m.At(IPt(63, 0)))
+}
+
+func Test_ToMatrix(t *testing.T) {
+	mat := MatrixFromString(`
+x---x--
+--x-xx-
+--x---x
+`, map[byte]bool{'x': true})
+	m := MatBoolFromMatrix(mat)
+	assert.Equal(t, I(7), m.Count())
+	assert.Equal(t, mat, m.ToMatrix())
+}
+
+func Test_TryRandomUnoccupiedPos(t *testing.T) {
+	RSeed(I(0))
+	m := NewMatBool(IPt(9, 9))
+	m.SetAll()
+	_, err := m.TryRandomUnoccupiedPos()
+	assert.Error(t, err)
+
+	// The only free position is always found, including in the last word.
+	m.Clear(IPt(8, 8))
+	for i := 0; i < 10; i++ {
+		pos, err := m.TryRandomUnoccupiedPos()
+		assert.NoError(t, err)
+		assert.Equal(t, IPt(8, 8), pos)
+	}
+
+	// All free positions get chosen.
+	m.Clear(IPt(0, 0))
+	m.Clear(IPt(3, 5))
+	found := NewMatBool(m.Size())
+	for i := 0; i < 100; i++ {
+		pos, err := m.TryRandomUnoccupiedPos()
+		assert.NoError(t, err)
+		found.Set(pos)
+	}
+	assert.Equal(t, []Pt{IPt(0, 0), IPt(3, 5), IPt(8, 8)}, found.ToSlice())
+}
+
+func Test_OccupyRandomPositions(t *testing.T) {
+	RSeed(I(0))
+	m := NewMatBool(IPt(5, 5))
+	pts, err := m.OccupyRandomPositions(I(20))
+	assert.NoError(t, err)
+	assert.Equal(t, 20, len(pts))
+	assert.Equal(t, I(20), m.Count())
+
+	// Not enough room, nothing changes.
+	before := m.Clone()
+	pts, err = m.OccupyRandomPositions(I(6))
+	assert.Error(t, err)
+	assert.Empty(t, pts)
+	assert.Equal(t, before, m)
+
+	// Exactly enough room fills the matrix.
+	_, err = m.OccupyRandomPositions(I(5))
+	assert.NoError(t, err)
+	assert.Equal(t, I(25), m.Count())
+}
+
+func Test_OutOfBounds(t *testing.T) {
+	// 3 x 3 positions use 9 bits of a 64 bit word, so positions past the end
+	// would still fit in the word.
+	m := NewMatBool(IPt(3, 3))
+	assert.Panics(t, func() { m.Set(IPt(3, 0)) })
+	assert.Panics(t, func() { m.Set(IPt(0, 3)) })
+	assert.Panics(t, func() { m.Get(IPt(-1, 0)) })
+	assert.Panics(t, func() { m.Clear(IPt(1, 5)) })
+	assert.Equal(t, ZERO, m.Count())
+}
+
+func Test_JSON(t *testing.T) {
+	m := NewMatBool(IPt(3, 2))
+	m.Set(IPt(0, 0))
+	m.Set(IPt(2, 1))
+	data, err := json.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, `["x--","--x"]`, string(data))
+
+	var loaded MatBool
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, m, loaded)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`["x--","-x"]`), &loaded))
+}
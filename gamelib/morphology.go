@@ -0,0 +1,110 @@
+package gamelib
+
+import (
+	"math"
+)
+
+/*
+Morphology operations treat the positions that are true in a MatBool as a
+shape and grow or shrink that shape.
+
+The main use is to account for things that are larger than a cell. For
+example, if obstacles are dilated by the radius of a character, then the
+character can be treated as a single cell during pathfinding: if its center is
+in a free cell, its whole body is clear of the obstacles.
+
+All operations take the directions that define which cells are neighbors, so
+either Directions4() or Directions8(). With Directions4() distances are
+measured in steps along the axes, so a dilated point becomes a diamond. With
+Directions8() diagonal steps count as 1, so a dilated point becomes a square.
+
+Positions outside the matrix are ignored. They don't grow into the matrix
+during dilation and they don't eat into the shape during erosion.
+*/
+
+// DistanceTransform returns, for each position, the number of steps to the
+// closest position that is true. Positions that are true get 0. If no
+// position is true, all positions get -1.
+func (m *MatBool) DistanceTransform(dirs []Pt) (res Matrix[Int]) {
+	dist := m.distances(dirs, math.MaxInt)
+	res = NewMatrix[Int](m.size)
+	for i := range dist {
+		res.cells[i] = I(dist[i])
+	}
+	return
+}
+
+// distances does a breadth-first search starting from all the positions that
+// are true and returns the number of steps to each position. Positions
+// farther than maxDist, or not reachable at all, get -1.
+func (m *MatBool) distances(dirs []Pt, maxDist int) []int {
+	nCells := m.size.X.Times(m.size.Y).ToInt()
+	dist := make([]int, nCells)
+	for i := range dist {
+		dist[i] = -1
+	}
+
+	queue := make([]int, 0, nCells)
+	m.forEachIndex(func(i int) {
+		dist[i] = 0
+		queue = append(queue, i)
+	})
+
+	for idx := 0; idx < len(queue); idx++ {
+		node := queue[idx]
+		if dist[node] == maxDist {
+			continue
+		}
+		pt := m.IndexToPt(I(node))
+		for _, d := range dirs {
+			neighbor := pt.Plus(d)
+			if !m.InBounds(neighbor) {
+				continue
+			}
+			n := m.PtToIndex(neighbor).ToInt()
+			if dist[n] < 0 {
+				dist[n] = dist[node] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	return dist
+}
+
+// Dilate sets all the positions that are at most radius steps away from a
+// position that is true.
+func (m *MatBool) Dilate(radius Int, dirs []Pt) {
+	if radius.IsNonPositive() {
+		return
+	}
+	dist := m.distances(dirs, radius.ToInt())
+	for i := range dist {
+		if dist[i] >= 0 {
+			m.words[i/wordBits] |= 1 << (i % wordBits)
+		}
+	}
+}
+
+// Erode clears all the positions that are at most radius steps away from a
+// position that is false.
+func (m *MatBool) Erode(radius Int, dirs []Pt) {
+	m.Negate()
+	m.Dilate(radius, dirs)
+	m.Negate()
+}
+
+// Open erodes and then dilates the matrix. This removes parts of the shape
+// that are thinner than the radius, while keeping the rest of the shape
+// mostly the same.
+func (m *MatBool) Open(radius Int, dirs []Pt) {
+	m.Erode(radius, dirs)
+	m.Dilate(radius, dirs)
+}
+
+// Close dilates and then erodes the matrix. This fills holes and gaps that
+// are narrower than the radius, while keeping the rest of the shape mostly
+// the same.
+func (m *MatBool) Close(radius Int, dirs []Pt) {
+	m.Dilate(radius, dirs)
+	m.Erode(radius, dirs)
+}
@@ -0,0 +1,122 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func matBoolFromString(str string) MatBool {
+	return MatBoolFromMatrix(MatrixFromString(str, map[byte]bool{'x': true}))
+}
+
+func TestMatBool_Dilate(t *testing.T) {
+	m := matBoolFromString(`
+-----
+-----
+--x--
+-----
+-----
+`)
+	m4 := m.Clone()
+	m4.Dilate(I(1), Directions4())
+	assert.Equal(t, matBoolFromString(`
+-----
+--x--
+-xxx-
+--x--
+-----
+`), m4)
+
+	m8 := m.Clone()
+	m8.Dilate(I(1), Directions8())
+	assert.Equal(t, matBoolFromString(`
+-----
+-xxx-
+-xxx-
+-xxx-
+-----
+`), m8)
+
+	m8.Dilate(I(2), Directions8())
+	assert.Equal(t, I(25), m8.Count())
+}
+
+func TestMatBool_Erode(t *testing.T) {
+	m := matBoolFromString(`
+xxxxx
+xxxxx
+xxxx-
+xxxxx
+`)
+	m.Erode(I(1), Directions4())
+	// Positions outside the matrix don't erode the shape.
+	assert.Equal(t, matBoolFromString(`
+xxxxx
+xxxx-
+xxx--
+xxxx-
+`), m)
+}
+
+func TestMatBool_OpenClose(t *testing.T) {
+	m := matBoolFromString(`
+----------
+-xxxx-----
+-xxxx---x-
+-xxxx-----
+-xxxx-----
+----------
+`)
+	// Opening removes the isolated point but keeps the block.
+	opened := m.Clone()
+	opened.Open(I(1), Directions8())
+	assert.Equal(t, matBoolFromString(`
+----------
+-xxxx-----
+-xxxx-----
+-xxxx-----
+-xxxx-----
+----------
+`), opened)
+
+	// Closing fills the hole in the block.
+	c := matBoolFromString(`
+---------
+---------
+--xxxxx--
+--xx-xx--
+--xxxxx--
+---------
+---------
+`)
+	c.Close(I(1), Directions8())
+	assert.Equal(t, matBoolFromString(`
+---------
+---------
+--xxxxx--
+--xxxxx--
+--xxxxx--
+---------
+---------
+`), c)
+}
+
+func TestMatBool_DistanceTransform(t *testing.T) {
+	m := matBoolFromString(`
+x----
+-----
+-----
+`)
+	d4 := m.DistanceTransform(Directions4())
+	assert.Equal(t, ZERO, d4.Get(IPt(0, 0)))
+	assert.Equal(t, I(4), d4.Get(IPt(4, 0)))
+	assert.Equal(t, I(6), d4.Get(IPt(4, 2)))
+
+	d8 := m.DistanceTransform(Directions8())
+	assert.Equal(t, I(4), d8.Get(IPt(4, 2)))
+	assert.Equal(t, I(2), d8.Get(IPt(2, 2)))
+
+	empty := NewMatBool(IPt(3, 3))
+	dEmpty := empty.DistanceTransform(Directions8())
+	assert.Equal(t, I(-1), dEmpty.Get(IPt(1, 1)))
+}
@@ -0,0 +1,186 @@
+package gamelib
+
+/*
+Noise generates 2D coherent noise using only integers.
+
+Coherent noise gives random values that change smoothly from one position to
+the next. This makes it useful for things like ground textures or deciding how
+likely food is to spawn in an area. Since the simulation only uses integers
+(see Int in ints.go), the usual floating point implementations are not an
+option.
+
+The noise is computed on a lattice. The positions are divided into square
+cells of size cellSize. Each corner of a cell gets a pseudo-random value from a
+hash of its coordinates and the seed. The value at a position is interpolated
+from the corners of its cell. This means:
+- the same seed and position always give the same value
+- there's no state, so values can be computed for any position in any order
+- cellSize controls how quickly the noise changes
+
+Two kinds of noise are supported:
+- value noise: corners get random values, which get interpolated
+- gradient noise (Perlin noise): corners get random directions and the value
+at a position depends on how much it lies in the direction of each corner
+
+Gradient noise looks less blocky, value noise is cheaper.
+
+Fractal versions add several layers (octaves) of noise, each one with half
+the cell size and half the weight of the previous one. This adds detail on top
+of the large scale features.
+
+Internally, fractions are represented in fixed point, with noiseOne standing
+for 1.
+*/
+
+const noiseShift = 16
+const noiseOne = 1 << noiseShift
+
+// NoiseMax is the largest value returned by the noise functions. All values
+// are in the interval [0, NoiseMax].
+var NoiseMax = I(noiseOne)
+
+type Noise struct {
+	seed uint64
+}
+
+func NewNoise(seed Int) (n Noise) {
+	n.seed = uint64(seed.ToInt64())
+	return
+}
+
+// hash returns a pseudo-random number for a corner of the lattice.
+// This is the finalizer of the SplitMix64 generator. It works on uint64 so
+// that overflow wraps around, which is what we want here, so it doesn't use
+// Int.
+func (n Noise) hash(x, y Int, octave int) uint64 {
+	h := n.seed
+	h ^= uint64(x.ToInt64()) * 0x9E3779B97F4A7C15
+	h ^= uint64(y.ToInt64()) * 0xC2B2AE3D27D4EB4F
+	h ^= uint64(octave) * 0x165667B19E3779F9
+	h ^= h >> 30
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 27
+	h *= 0x94D049BB133111EB
+	h ^= h >> 31
+	return h
+}
+
+// floorDivMod divides a by b (b > 0) rounding towards negative infinity, so
+// that the remainder is always in [0, b). Int.DivBy rounds towards zero, which
+// would make the cells around 0 different from the rest.
+func floorDivMod(a, b Int) (q Int, r Int) {
+	q = a.DivBy(b)
+	r = a.Mod(b)
+	if r.IsNegative() {
+		q.Dec()
+		r.Add(b)
+	}
+	return
+}
+
+// cell returns the corner of the cell that contains pt and the position of pt
+// inside the cell, as fractions of noiseOne.
+func cell(pt Pt, cellSize Int) (corner Pt, frac Pt) {
+	var rem Pt
+	corner.X, rem.X = floorDivMod(pt.X, cellSize)
+	corner.Y, rem.Y = floorDivMod(pt.Y, cellSize)
+	frac = rem.Times(I(noiseOne)).DivBy(cellSize)
+	return
+}
+
+// fade smooths the interpolation, so that there are no visible edges between
+// cells: 6t^5 - 15t^4 + 10t^3.
+func fade(t Int) Int {
+	one := I(noiseOne)
+	t3 := t.Times(t).DivBy(one).Times(t).DivBy(one)
+	inner := t.Times(I(6)).Minus(one.Times(I(15))).Times(t).DivBy(one).Plus(one.Times(I(10)))
+	return t3.Times(inner).DivBy(one)
+}
+
+// lerp interpolates between a and b, where t is a fraction of noiseOne.
+func lerp(a, b, t Int) Int {
+	return a.Plus(b.Minus(a).Times(t).DivBy(I(noiseOne)))
+}
+
+func (n Noise) value(pt Pt, cellSize Int, octave int) Int {
+	c, f := cell(pt, cellSize)
+	corner := func(dx, dy Int) Int {
+		return I64(int64(n.hash(c.X.Plus(dx), c.Y.Plus(dy), octave) % (noiseOne + 1)))
+	}
+	u := fade(f.X)
+	v := fade(f.Y)
+	top := lerp(corner(ZERO, ZERO), corner(ONE, ZERO), u)
+	bottom := lerp(corner(ZERO, ONE), corner(ONE, ONE), u)
+	return lerp(top, bottom, v)
+}
+
+func (n Noise) gradient(pt Pt, cellSize Int, octave int) Int {
+	c, f := cell(pt, cellSize)
+	one := I(noiseOne)
+	corner := func(dx, dy Int) Int {
+		// Use the diagonals as gradients. The value of a single corner is
+		// in [-2*noiseOne, 2*noiseOne], as both offsets can be up to
+		// noiseOne.
+		h := n.hash(c.X.Plus(dx), c.Y.Plus(dy), octave)
+		offset := Pt{f.X.Minus(dx.Times(one)), f.Y.Minus(dy.Times(one))}
+		if h&1 != 0 {
+			offset.X = offset.X.Negative()
+		}
+		if h&2 != 0 {
+			offset.Y = offset.Y.Negative()
+		}
+		return offset.X.Plus(offset.Y)
+	}
+	u := fade(f.X)
+	v := fade(f.Y)
+	top := lerp(corner(ZERO, ZERO), corner(ONE, ZERO), u)
+	bottom := lerp(corner(ZERO, ONE), corner(ONE, ONE), u)
+	val := lerp(top, bottom, v)
+	// After interpolation the values are mostly in [-noiseOne, noiseOne],
+	// but this isn't guaranteed, so clamp them while moving to
+	// [0, noiseOne].
+	return Max(ZERO, Min(one, val.Plus(one).DivBy(TWO)))
+}
+
+// Value returns value noise at pt, in [0, NoiseMax]. cellSize is the distance
+// between the corners of the lattice and must be positive.
+func (n Noise) Value(pt Pt, cellSize Int) Int {
+	return n.value(pt, cellSize, 0)
+}
+
+// Gradient returns gradient (Perlin) noise at pt, in [0, NoiseMax]. cellSize
+// is the distance between the corners of the lattice and must be positive.
+func (n Noise) Gradient(pt Pt, cellSize Int) Int {
+	return n.gradient(pt, cellSize, 0)
+}
+
+// FractalValue adds octaves layers of value noise, in [0, NoiseMax].
+func (n Noise) FractalValue(pt Pt, cellSize Int, octaves Int) Int {
+	return n.fractal(pt, cellSize, octaves, n.value)
+}
+
+// FractalGradient adds octaves layers of gradient noise, in [0, NoiseMax].
+func (n Noise) FractalGradient(pt Pt, cellSize Int, octaves Int) Int {
+	return n.fractal(pt, cellSize, octaves, n.gradient)
+}
+
+func (n Noise) fractal(pt Pt, cellSize Int, octaves Int,
+	noise func(pt Pt, cellSize Int, octave int) Int) Int {
+	sum := ZERO
+	totalWeight := ZERO
+	weight := I(noiseOne)
+	for octave := 0; I(octave).Lt(octaves); octave++ {
+		// Stop when cells become smaller than the smallest unit.
+		if cellSize.Lt(ONE) || weight.IsZero() {
+			break
+		}
+		sum.Add(noise(pt, cellSize, octave).Times(weight))
+		totalWeight.Add(weight)
+		cellSize = cellSize.DivBy(TWO)
+		weight = weight.DivBy(TWO)
+	}
+	if totalWeight.IsZero() {
+		return ZERO
+	}
+	return sum.DivBy(totalWeight)
+}
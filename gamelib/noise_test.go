@@ -0,0 +1,59 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNoise_Range(t *testing.T) {
+	n := NewNoise(I(42))
+	cellSize := U(10)
+	minVal, maxVal := NoiseMax, ZERO
+	for y := U(-30); y.Lt(U(30)); y.Add(I(37)) {
+		for x := U(-30); x.Lt(U(30)); x.Add(I(41)) {
+			pt := Pt{x, y}
+			for _, v := range []Int{
+				n.Value(pt, cellSize),
+				n.Gradient(pt, cellSize),
+				n.FractalValue(pt, cellSize, I(4)),
+				n.FractalGradient(pt, cellSize, I(4)),
+			} {
+				assert.True(t, v.Between(ZERO, NoiseMax))
+				minVal = Min(minVal, v)
+				maxVal = Max(maxVal, v)
+			}
+		}
+	}
+	// The values actually cover a good part of the range.
+	assert.True(t, minVal.Lt(NoiseMax.DivBy(I(4))))
+	assert.True(t, maxVal.Gt(NoiseMax.Times(I(3)).DivBy(I(4))))
+}
+
+func TestNoise_Deterministic(t *testing.T) {
+	pt := UPt(123, -45)
+	assert.Equal(t, NewNoise(I(7)).FractalGradient(pt, U(16), I(3)),
+		NewNoise(I(7)).FractalGradient(pt, U(16), I(3)))
+
+	// Different seeds give different noise.
+	different := false
+	for x := 0; x < 10; x++ {
+		pt = UPt(x*7, 3)
+		if NewNoise(I(7)).Value(pt, U(5)).Neq(NewNoise(I(8)).Value(pt, U(5))) {
+			different = true
+		}
+	}
+	assert.True(t, different)
+}
+
+func TestNoise_Smooth(t *testing.T) {
+	n := NewNoise(I(3))
+	cellSize := U(20)
+	maxStep := NoiseMax.DivBy(I(50))
+	// Check across 0 too, where rounding towards zero would cause trouble.
+	for x := U(-25); x.Lt(U(25)); x.Inc() {
+		pt1 := Pt{x, U(3)}
+		pt2 := Pt{x.Plus(ONE), U(3)}
+		assert.True(t, n.Value(pt1, cellSize).Minus(n.Value(pt2, cellSize)).Abs().Leq(maxStep))
+		assert.True(t, n.Gradient(pt1, cellSize).Minus(n.Gradient(pt2, cellSize)).Abs().Leq(maxStep))
+	}
+}
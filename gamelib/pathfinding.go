@@ -75,9 +75,39 @@ func (p *Pathfinding[T]) computePath(parents []int, end int) (path []Pt) {
 }
 
 func (p *Pathfinding[T]) FindPath(startPt, endPt Pt) []Pt {
+	end := p.m.PtToIndex(endPt).ToInt()
+	if p.search(startPt, func(node int) bool { return node == end }) < 0 {
+		return []Pt{}
+	}
+	return p.computePath(p.parents, end)
+}
+
+// FindPathToNearest returns the path to the closest position marked in
+// targets, along with that position. If no target can be reached, the path is
+// empty.
+func (p *Pathfinding[T]) FindPathToNearest(startPt Pt, targets MatBool) (path []Pt, target Pt) {
+	return p.FindPathToNearestFunc(startPt, targets.At)
+}
+
+// FindPathToNearestFunc returns the path to the closest position for which
+// isTarget returns true, along with that position. If no target can be
+// reached, the path is empty.
+func (p *Pathfinding[T]) FindPathToNearestFunc(startPt Pt, isTarget func(pt Pt) bool) (path []Pt, target Pt) {
+	found := p.search(startPt, func(node int) bool {
+		return isTarget(p.m.IndexToPt(I(node)))
+	})
+	if found < 0 {
+		return []Pt{}, Pt{}
+	}
+	return p.computePath(p.parents, found), p.m.IndexToPt(I(found))
+}
+
+// search does a breadth-first search from startPt and stops at the first node
+// for which isEnd returns true. It returns that node or -1 if no such node is
+// reachable. The path to the node can be computed from p.parents.
+func (p *Pathfinding[T]) search(startPt Pt, isEnd func(node int) bool) int {
 	// Convert Pts to ints.
 	start := p.m.PtToIndex(startPt).ToInt()
-	end := p.m.PtToIndex(endPt).ToInt()
 
 	// Initialize our structures.
 	p.queue = p.queue[:0] // Make len(p.queue) == 0 without re-allocating.
@@ -94,8 +124,8 @@ func (p *Pathfinding[T]) FindPath(startPt, endPt Pt) []Pt {
 	for idx < len(p.queue) {
 		// peek the first element from the queue
 		topEl := p.queue[idx]
-		if topEl == end {
-			return p.computePath(p.parents, end)
+		if isEnd(topEl) {
+			return topEl
 		}
 
 		nIndex := topEl * p.nDirs
@@ -111,10 +141,15 @@ func (p *Pathfinding[T]) FindPath(startPt, endPt Pt) []Pt {
 		// pop the first element out of the queue
 		idx++
 	}
-	return []Pt{}
+	return -1
 }
 
 func FindPath[T comparable](startPt, endPt Pt, m Matrix[T], emptyVal T) []Pt {
 	p := NewPathfinding(m, emptyVal)
 	return p.FindPath(startPt, endPt)
 }
+
+func FindPathToNearest[T comparable](startPt Pt, targets MatBool, m Matrix[T], emptyVal T) (path []Pt, target Pt) {
+	p := NewPathfinding(m, emptyVal)
+	return p.FindPathToNearest(startPt, targets)
+}
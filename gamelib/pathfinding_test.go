@@ -0,0 +1,53 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFindPathToNearest(t *testing.T) {
+	m := MatrixFromString(`
+------
+-xxxx-
+------
+`, map[byte]bool{'x': true, '-': false})
+	targets := MatBoolFromMatrix(MatrixFromString(`
+-----f
+------
+f-----
+`, map[byte]bool{'f': true}))
+
+	path, target := FindPathToNearest(IPt(3, 2), targets, m, false)
+	assert.Equal(t, IPt(0, 2), target)
+	assert.Equal(t, []Pt{IPt(3, 2), IPt(2, 2), IPt(1, 2), IPt(0, 2)}, path)
+
+	path, target = FindPathToNearest(IPt(4, 0), targets, m, false)
+	assert.Equal(t, IPt(5, 0), target)
+	assert.Equal(t, []Pt{IPt(4, 0), IPt(5, 0)}, path)
+
+	// The start counts as a target, if it is one.
+	path, target = FindPathToNearest(IPt(5, 0), targets, m, false)
+	assert.Equal(t, IPt(5, 0), target)
+	assert.Equal(t, []Pt{IPt(5, 0)}, path)
+}
+
+func TestFindPathToNearestFunc(t *testing.T) {
+	m := MatrixFromString(`
+--x---
+--x---
+--x---
+`, map[byte]bool{'x': true, '-': false})
+	p := NewPathfinding(m, false)
+
+	// Targets on the other side of the wall can't be reached.
+	path, _ := p.FindPathToNearestFunc(IPt(0, 0), func(pt Pt) bool {
+		return pt.X.Gt(I(2))
+	})
+	assert.Empty(t, path)
+
+	path, target := p.FindPathToNearestFunc(IPt(0, 0), func(pt Pt) bool {
+		return pt.Y.Eq(I(2))
+	})
+	assert.Equal(t, IPt(0, 2), target)
+	assert.Equal(t, 3, len(path))
+}
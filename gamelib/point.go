@@ -99,6 +99,13 @@ func (p Pt) Dot(other Pt) Int {
 	return p.X.Times(other.X).Plus(p.Y.Times(other.Y))
 }
 
+// Cross returns the z component of the cross product of p and other, seen as
+// 3D vectors with z = 0. It is positive if other is clockwise from p, when Y
+// points down.
+func (p Pt) Cross(other Pt) Int {
+	return p.X.Times(other.Y).Minus(p.Y.Times(other.X))
+}
+
 func (p *Pt) SetLen(newLen Int) {
 	oldLen := p.Len()
 	if oldLen.Eq(I(0)) {
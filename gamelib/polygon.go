@@ -0,0 +1,226 @@
+package gamelib
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Area is implemented by shapes that limit where something can be, such as
+// the part of a room where a character is allowed to walk.
+type Area interface {
+	ContainsPt(pt Pt) bool
+	// ClosestPt returns the point inside the area that is closest to pt. If
+	// pt is inside the area, it returns pt.
+	ClosestPt(pt Pt) Pt
+}
+
+// UnmarshalArea reads an Area saved as JSON. Areas are saved as the shape
+// that implements them, so this works for *Rectangle and Polygon, the shapes
+// that have fields which tell them apart. A JSON null gives a nil Area.
+func UnmarshalArea(data []byte) (Area, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	switch {
+	case fields == nil:
+		return nil, nil
+	case fields["Pts"] != nil:
+		var p Polygon
+		err := json.Unmarshal(data, &p)
+		return p, err
+	case fields["Corner1"] != nil:
+		var r Rectangle
+		err := json.Unmarshal(data, &r)
+		return &r, err
+	default:
+		return nil, fmt.Errorf("unknown area: %s", data)
+	}
+}
+
+// Polygon is a closed shape made of the segments between consecutive
+// points, plus the segment between the last and the first point. The points
+// can be in any order (clockwise or counter-clockwise), but the edges must
+// not cross each other. Polygons include their edges.
+type Polygon struct {
+	Pts []Pt
+}
+
+// NewPolygon creates a polygon with its own copy of pts. It crashes if there
+// are fewer than 3 points, as they can't enclose anything.
+func NewPolygon(pts ...Pt) (p Polygon) {
+	p.Pts = slices.Clone(pts)
+	p.checkValid()
+	return
+}
+
+// Clone returns a copy of p that doesn't share its points with p. Copying a
+// Polygon by value shares the points, so clone it before changing them.
+func (p Polygon) Clone() Polygon {
+	return Polygon{slices.Clone(p.Pts)}
+}
+
+func (p Polygon) checkValid() {
+	if len(p.Pts) < 3 {
+		Check(fmt.Errorf("a polygon needs at least 3 points, got %d",
+			len(p.Pts)))
+	}
+}
+
+// Edge returns the segment between point i and the point after it.
+func (p Polygon) Edge(i int) Line {
+	p.checkValid()
+	return Line{p.Pts[i], p.Pts[(i+1)%len(p.Pts)]}
+}
+
+// Bounds returns the smallest rectangle that contains the polygon.
+func (p Polygon) Bounds() Rectangle {
+	p.checkValid()
+	minPt, maxPt := p.Pts[0], p.Pts[0]
+	for _, pt := range p.Pts {
+		minPt = Pt{Min(minPt.X, pt.X), Min(minPt.Y, pt.Y)}
+		maxPt = Pt{Max(maxPt.X, pt.X), Max(maxPt.Y, pt.Y)}
+	}
+	return Rectangle{minPt, maxPt}
+}
+
+// doubleArea returns twice the signed area of the polygon. It is positive if
+// the points go clockwise on the screen (where Y points down).
+func (p Polygon) doubleArea() Int {
+	area := ZERO
+	for i := range p.Pts {
+		e := p.Edge(i)
+		area.Add(e.Start.Cross(e.End))
+	}
+	return area
+}
+
+// ContainsPt returns true if pt is inside the polygon or on one of its edges.
+func (p Polygon) ContainsPt(pt Pt) bool {
+	inside := false
+	for i := range p.Pts {
+		e := p.Edge(i)
+		if onSegment(e, pt) {
+			return true
+		}
+
+		// Count how many edges a horizontal ray from pt to the right crosses.
+		// The ray crosses the edge to the right of pt if the x of the crossing
+		// is greater than pt.X. Compare without dividing, so there's no
+		// rounding: (xCrossing - pt.X) * dy has the same sign as dy if the
+		// edge is to the right.
+		if e.Start.Y.Gt(pt.Y) == e.End.Y.Gt(pt.Y) {
+			continue
+		}
+		dy := e.End.Y.Minus(e.Start.Y)
+		dx := e.End.X.Minus(e.Start.X)
+		v := e.Start.X.Minus(pt.X).Times(dy).Plus(pt.Y.Minus(e.Start.Y).Times(dx))
+		if v.IsPositive() == dy.IsPositive() {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// onSegment returns true if pt is exactly on l.
+func onSegment(l Line, pt Pt) bool {
+	if !l.Start.To(l.End).Cross(l.Start.To(pt)).IsZero() {
+		return false
+	}
+	minX, maxX := MinMax(l.Start.X, l.End.X)
+	minY, maxY := MinMax(l.Start.Y, l.End.Y)
+	return pt.X.Geq(minX) && pt.X.Leq(maxX) && pt.Y.Geq(minY) && pt.Y.Leq(maxY)
+}
+
+// ClosestPt returns the point inside the polygon that is closest to pt. If pt
+// is inside the polygon, it returns pt.
+func (p Polygon) ClosestPt(pt Pt) Pt {
+	if p.ContainsPt(pt) {
+		return pt
+	}
+
+	closest := ClosestPointOnLine(p.Edge(0), pt)
+	for i := 1; i < len(p.Pts); i++ {
+		candidate := ClosestPointOnLine(p.Edge(i), pt)
+		if pt.SquaredDistTo(candidate).Lt(pt.SquaredDistTo(closest)) {
+			closest = candidate
+		}
+	}
+
+	// For slanted edges, the closest point gets rounded to integers and may
+	// end up just outside the polygon. In that case, use the best neighbor
+	// that is inside.
+	if p.ContainsPt(closest) {
+		return closest
+	}
+	found := false
+	var best Pt
+	for _, d := range Directions8() {
+		candidate := closest.Plus(d)
+		if !p.ContainsPt(candidate) {
+			continue
+		}
+		if !found || pt.SquaredDistTo(candidate).Lt(pt.SquaredDistTo(best)) {
+			found = true
+			best = candidate
+		}
+	}
+	if found {
+		return best
+	}
+	return closest
+}
+
+// LineIntersection implements Raycastable.
+func (p Polygon) LineIntersection(l Line) (bool, Pt, Pt) {
+	if p.ContainsPt(l.Start) {
+		return false, Pt{}, Pt{}
+	}
+
+	clockwise := p.doubleArea().IsPositive()
+	dir := l.Start.To(l.End)
+	found := false
+	var closest, closestNormal Pt
+	for i := range p.Pts {
+		e := p.Edge(i)
+		d := e.Start.To(e.End)
+		normal := Pt{d.Y, d.X.Negative()}
+		if !clockwise {
+			normal = normal.Times(I(-1))
+		}
+		// A line can only enter through the edges that face its start.
+		if !normal.Dot(dir).IsNegative() {
+			continue
+		}
+		ok, pt := LineLineIntersection(l, e)
+		if !ok {
+			continue
+		}
+		if !found || l.Start.SquaredDistTo(pt).Lt(l.Start.SquaredDistTo(closest)) {
+			found = true
+			closest = pt
+			closestNormal = normal
+		}
+	}
+	return found, closest, closestNormal
+}
+
+// LinePolygonIntersection returns the point where l first crosses an edge of
+// the polygon, going from l.Start to l.End. Unlike LineIntersection, it also
+// finds the point where l leaves the polygon, if l.Start is inside.
+func LinePolygonIntersection(l Line, p Polygon) (bool, Pt) {
+	found := false
+	var closest Pt
+	for i := range p.Pts {
+		ok, pt := LineLineIntersection(l, p.Edge(i))
+		if !ok {
+			continue
+		}
+		if !found || l.Start.SquaredDistTo(pt).Lt(l.Start.SquaredDistTo(closest)) {
+			found = true
+			closest = pt
+		}
+	}
+	return found, closest
+}
@@ -0,0 +1,146 @@
+package gamelib
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// An L shaped room:
+// xxxxx
+// xxxxx
+// xx
+// xx
+func lShape() Polygon {
+	return Polygon{[]Pt{IPt(0, 0), IPt(50, 0), IPt(50, 20), IPt(20, 20),
+		IPt(20, 40), IPt(0, 40)}}
+}
+
+func TestPolygon_ContainsPt(t *testing.T) {
+	p := lShape()
+	assert.True(t, p.ContainsPt(IPt(10, 10)))
+	assert.True(t, p.ContainsPt(IPt(40, 10)))
+	assert.True(t, p.ContainsPt(IPt(10, 30)))
+	assert.False(t, p.ContainsPt(IPt(40, 30)))
+	assert.False(t, p.ContainsPt(IPt(-1, 10)))
+	assert.False(t, p.ContainsPt(IPt(60, 10)))
+
+	// Edges and corners are inside.
+	assert.True(t, p.ContainsPt(IPt(0, 0)))
+	assert.True(t, p.ContainsPt(IPt(30, 20)))
+	assert.True(t, p.ContainsPt(IPt(20, 30)))
+	assert.True(t, p.ContainsPt(IPt(20, 20)))
+
+	// The order of the points doesn't matter.
+	reversed := Polygon{[]Pt{IPt(0, 40), IPt(20, 40), IPt(20, 20), IPt(50, 20),
+		IPt(50, 0), IPt(0, 0)}}
+	assert.True(t, reversed.ContainsPt(IPt(10, 30)))
+	assert.False(t, reversed.ContainsPt(IPt(40, 30)))
+
+	// Slanted edges.
+	triangle := Polygon{[]Pt{IPt(0, 0), IPt(100, 0), IPt(0, 100)}}
+	assert.True(t, triangle.ContainsPt(IPt(49, 50)))
+	assert.True(t, triangle.ContainsPt(IPt(50, 50)))
+	assert.False(t, triangle.ContainsPt(IPt(51, 50)))
+}
+
+func TestPolygon_ClosestPt(t *testing.T) {
+	p := lShape()
+	assert.Equal(t, IPt(10, 10), p.ClosestPt(IPt(10, 10)))
+	assert.Equal(t, IPt(50, 10), p.ClosestPt(IPt(70, 10)))
+	assert.Equal(t, IPt(20, 35), p.ClosestPt(IPt(30, 35)))
+	assert.Equal(t, IPt(0, 0), p.ClosestPt(IPt(-5, -5)))
+
+	// The closest point of slanted edges is always inside.
+	triangle := Polygon{[]Pt{IPt(0, 0), IPt(100, 0), IPt(0, 100)}}
+	for x := 40; x < 100; x += 7 {
+		for y := 40; y < 100; y += 3 {
+			if x+y <= 100 {
+				continue
+			}
+			pt := triangle.ClosestPt(IPt(x, y))
+			assert.True(t, triangle.ContainsPt(pt))
+			assert.True(t, pt.X.Plus(pt.Y).Geq(I(98)))
+		}
+	}
+}
+
+func TestPolygon_LineIntersection(t *testing.T) {
+	p := lShape()
+
+	// Enter through the right edge of the bottom part.
+	hit, pt, normal := p.LineIntersection(Line{IPt(40, 30), IPt(0, 30)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(20, 30), pt)
+	assert.Equal(t, I(0), normal.Y)
+	assert.True(t, normal.X.IsPositive())
+
+	// Enter through the top edge.
+	hit, pt, normal = p.LineIntersection(Line{IPt(30, -10), IPt(30, 10)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(30, 0), pt)
+	assert.Equal(t, I(0), normal.X)
+	assert.True(t, normal.Y.IsNegative())
+
+	// Miss.
+	hit, _, _ = p.LineIntersection(Line{IPt(40, 30), IPt(40, 50)})
+	assert.False(t, hit)
+
+	// Starting inside is not a hit, but LinePolygonIntersection finds where
+	// the line leaves.
+	l := Line{IPt(10, 10), IPt(10, 60)}
+	hit, _, _ = p.LineIntersection(l)
+	assert.False(t, hit)
+	ok, pt := LinePolygonIntersection(l, p)
+	assert.True(t, ok)
+	assert.Equal(t, IPt(10, 40), pt)
+
+	// Polygons work with Raycast.
+	shapes := []Raycastable{p, &Rectangle{IPt(60, 0), IPt(70, 10)}}
+	ok, h := Raycast(Line{IPt(100, 5), IPt(-100, 5)}, shapes)
+	assert.True(t, ok)
+	assert.Equal(t, 1, h.ShapeIdx)
+}
+
+func TestArea(t *testing.T) {
+	areas := []Area{
+		lShape(),
+		&Rectangle{IPt(0, 0), IPt(50, 40)},
+		Circle{IPt(0, 0), I(100)},
+	}
+	for _, a := range areas {
+		pt := a.ClosestPt(IPt(300, 300))
+		assert.True(t, a.ContainsPt(pt))
+		assert.Equal(t, IPt(5, 5), a.ClosestPt(IPt(5, 5)))
+	}
+	r := Rectangle{IPt(0, 0), IPt(50, 40)}
+	assert.Equal(t, IPt(50, 40), r.ClosestPt(IPt(300, 300)))
+}
+
+func TestPolygon_NewPolygon(t *testing.T) {
+	pts := []Pt{IPt(0, 0), IPt(100, 0), IPt(0, 100)}
+	p := NewPolygon(pts...)
+	c := p.Clone()
+	pts[0] = IPt(-50, -50)
+	c.Pts[1] = IPt(200, 0)
+	assert.Equal(t, IPt(0, 0), p.Pts[0])
+	assert.Equal(t, IPt(100, 0), p.Pts[1])
+
+	assert.Panics(t, func() { NewPolygon(IPt(0, 0), IPt(1, 1)) })
+	assert.Panics(t, func() { Polygon{}.Bounds() })
+	assert.False(t, Polygon{}.ContainsPt(IPt(0, 0)))
+}
+
+func TestUnmarshalArea(t *testing.T) {
+	areas := []Area{lShape(), &Rectangle{IPt(1, 2), IPt(3, 4)}, nil}
+	for _, a := range areas {
+		data, err := json.Marshal(a)
+		assert.Nil(t, err)
+		loaded, err := UnmarshalArea(data)
+		assert.Nil(t, err)
+		assert.Equal(t, a, loaded)
+	}
+
+	_, err := UnmarshalArea([]byte(`{"Center":{}}`))
+	assert.NotNil(t, err)
+}
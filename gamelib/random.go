@@ -6,21 +6,22 @@ import (
 	"time"
 )
 
-var randomGenerator *rand.Rand
-
-func init() {
-	// randomGenerator = rand.New(rand.NewSource(0))
-	randomGenerator = rand.New(rand.NewSource(time.Now().Unix()))
+// Rand is a random number generator that is separate from the global one used
+// by RInt. Code that must give the same results for the same seed, such as
+// level generation, should use its own Rand, so that it neither depends on
+// nor changes what the global generator returns.
+type Rand struct {
+	r *rand.Rand
 }
 
-func RSeed(seed Int) {
-	randomGenerator = rand.New(rand.NewSource(seed.ToInt64()))
+func NewRand(seed Int) *Rand {
+	return &Rand{rand.New(rand.NewSource(seed.ToInt64()))}
 }
 
-// RInt returns a random number in the interval [min, max].
+// Int returns a random number in the interval [min, max].
 // min must be smaller than max.
 // The difference between min and max must be at most max.MaxInt64 - 1.
-func RInt(min Int, max Int) Int {
+func (r *Rand) Int(min Int, max Int) Int {
 	if max.Lt(min) {
 		panic(fmt.Errorf("min larger than max: %d %d", min, max))
 	}
@@ -28,10 +29,27 @@ func RInt(min Int, max Int) Int {
 	dif := max.Minus(min).Plus(I(1)) // this will panic if the difference
 	// between min and max is greater than max.MaxInt64 - 1
 
-	randomValue := I64(randomGenerator.Int63())
+	randomValue := I64(r.r.Int63())
 	return randomValue.Mod(dif).Plus(min)
 }
 
+var randomGenerator *Rand
+
+func init() {
+	// randomGenerator = NewRand(I(0))
+	randomGenerator = NewRand(I64(time.Now().Unix()))
+}
+
+func RSeed(seed Int) {
+	randomGenerator = NewRand(seed)
+}
+
+// RInt returns a random number in the interval [min, max], using the global
+// random number generator. See Rand.Int.
+func RInt(min Int, max Int) Int {
+	return randomGenerator.Int(min, max)
+}
+
 // RElem returns a random element from a slice.
 func RElem[T any](s []T) T {
 	return s[RInt(I(0), I(len(s)-1)).ToInt()]
@@ -0,0 +1,131 @@
+package gamelib
+
+// Raycastable is implemented by shapes that can be hit by Raycast.
+type Raycastable interface {
+	// LineIntersection returns the first point where l enters the shape, when
+	// going from l.Start to l.End, and the normal of the surface at that
+	// point. The normal points out of the shape and is not normalized. If
+	// l.Start is inside the shape, l doesn't enter it, so there's no hit.
+	LineIntersection(l Line) (hit bool, pt Pt, normal Pt)
+}
+
+type RaycastHit struct {
+	Pt     Pt
+	Normal Pt
+	// Index of the shape that was hit, in the slice received by Raycast.
+	ShapeIdx int
+}
+
+// Raycast returns the first hit along ray, which goes from ray.Start to
+// ray.End. If several shapes are hit at the same point, the first one in
+// shapes wins.
+func Raycast(ray Line, shapes []Raycastable) (bool, RaycastHit) {
+	found := false
+	var closest RaycastHit
+	var closestDist Int
+	for i, s := range shapes {
+		hit, pt, normal := s.LineIntersection(ray)
+		if !hit {
+			continue
+		}
+		dist := ray.Start.SquaredDistTo(pt)
+		if !found || dist.Lt(closestDist) {
+			found = true
+			closest = RaycastHit{pt, normal, i}
+			closestDist = dist
+		}
+	}
+	return found, closest
+}
+
+// LineIntersection implements Raycastable.
+func (r *Rectangle) LineIntersection(l Line) (bool, Pt, Pt) {
+	minPt, maxPt := r.Min(), r.Max()
+	dir := l.Start.To(l.End)
+
+	// A line can only enter through the edges that face its start.
+	var pts, normals []Pt
+	if dir.X.IsPositive() {
+		left := Line{minPt, Pt{minPt.X, maxPt.Y}}
+		if ok, pt := LineVerticalLineIntersection(l, left); ok {
+			pts = append(pts, pt)
+			normals = append(normals, IPt(-1, 0))
+		}
+	}
+	if dir.X.IsNegative() {
+		right := Line{Pt{maxPt.X, minPt.Y}, maxPt}
+		if ok, pt := LineVerticalLineIntersection(l, right); ok {
+			pts = append(pts, pt)
+			normals = append(normals, IPt(1, 0))
+		}
+	}
+	if dir.Y.IsPositive() {
+		top := Line{minPt, Pt{maxPt.X, minPt.Y}}
+		if ok, pt := LineHorizontalLineIntersection(l, top); ok {
+			pts = append(pts, pt)
+			normals = append(normals, IPt(0, -1))
+		}
+	}
+	if dir.Y.IsNegative() {
+		bottom := Line{Pt{minPt.X, maxPt.Y}, maxPt}
+		if ok, pt := LineHorizontalLineIntersection(l, bottom); ok {
+			pts = append(pts, pt)
+			normals = append(normals, IPt(0, 1))
+		}
+	}
+
+	if len(pts) == 0 {
+		return false, Pt{}, Pt{}
+	}
+	closest := 0
+	for i := range pts {
+		if l.Start.SquaredDistTo(pts[i]).Lt(l.Start.SquaredDistTo(pts[closest])) {
+			closest = i
+		}
+	}
+	return true, pts[closest], normals[closest]
+}
+
+func (s Square) ToRectangle() Rectangle {
+	r := Rectangle{s.Center, s.Center}
+	return r.Expand(s.Size.DivBy(TWO))
+}
+
+// LineIntersection implements Raycastable.
+func (s Square) LineIntersection(l Line) (bool, Pt, Pt) {
+	r := s.ToRectangle()
+	return r.LineIntersection(l)
+}
+
+// LineIntersection implements Raycastable.
+func (c Circle) LineIntersection(l Line) (bool, Pt, Pt) {
+	r := c.Radius()
+	if c.Center.SquaredDistTo(l.Start).Lt(r.Sqr()) {
+		return false, Pt{}, Pt{}
+	}
+
+	dir := l.Start.To(l.End)
+	lenSqr := dir.SquaredLen()
+	if lenSqr.IsZero() {
+		return false, Pt{}, Pt{}
+	}
+
+	// Project the center on the line. The line goes through the circle if
+	// the projection is inside the circle.
+	proj := l.Start.Plus(dir.Times(l.Start.To(c.Center).Dot(dir)).DivBy(lenSqr))
+	distSqr := proj.SquaredDistTo(c.Center)
+	if distSqr.Gt(r.Sqr()) {
+		return false, Pt{}, Pt{}
+	}
+
+	// The line enters the circle half a chord before the projection.
+	halfChord := r.Sqr().Minus(distSqr).Sqrt()
+	pt := proj.Minus(dir.Times(halfChord).DivBy(dir.Len()))
+
+	// Check that the entry point is on the segment and not before or after.
+	t := l.Start.To(pt).Dot(dir)
+	if t.IsNegative() || t.Gt(lenSqr) {
+		return false, Pt{}, Pt{}
+	}
+	return true, pt, c.Center.To(pt)
+}
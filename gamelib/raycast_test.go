@@ -0,0 +1,89 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRectangle_LineIntersection(t *testing.T) {
+	r := Rectangle{IPt(10, 10), IPt(20, 20)}
+
+	hit, pt, normal := r.LineIntersection(Line{IPt(0, 15), IPt(30, 15)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(10, 15), pt)
+	assert.Equal(t, IPt(-1, 0), normal)
+
+	hit, pt, normal = r.LineIntersection(Line{IPt(15, 30), IPt(15, 0)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(15, 20), pt)
+	assert.Equal(t, IPt(0, 1), normal)
+
+	// Diagonal, entering through the top.
+	hit, pt, normal = r.LineIntersection(Line{IPt(8, 0), IPt(18, 20)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(13, 10), pt)
+	assert.Equal(t, IPt(0, -1), normal)
+
+	// Too short.
+	hit, _, _ = r.LineIntersection(Line{IPt(0, 15), IPt(9, 15)})
+	assert.False(t, hit)
+
+	// Starting inside.
+	hit, _, _ = r.LineIntersection(Line{IPt(15, 15), IPt(30, 15)})
+	assert.False(t, hit)
+}
+
+func TestCircle_LineIntersection(t *testing.T) {
+	c := Circle{IPt(50, 0), I(20)}
+
+	hit, pt, normal := c.LineIntersection(Line{IPt(0, 0), IPt(100, 0)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(40, 0), pt)
+	assert.Equal(t, IPt(-10, 0), normal)
+
+	hit, pt, _ = c.LineIntersection(Line{IPt(0, 6), IPt(100, 6)})
+	assert.True(t, hit)
+	assert.Equal(t, IPt(42, 6), pt)
+
+	// Passing by.
+	hit, _, _ = c.LineIntersection(Line{IPt(0, 11), IPt(100, 11)})
+	assert.False(t, hit)
+
+	// Going away.
+	hit, _, _ = c.LineIntersection(Line{IPt(0, 0), IPt(-100, 0)})
+	assert.False(t, hit)
+
+	// Too short.
+	hit, _, _ = c.LineIntersection(Line{IPt(0, 0), IPt(39, 0)})
+	assert.False(t, hit)
+
+	// Starting inside.
+	hit, _, _ = c.LineIntersection(Line{IPt(50, 0), IPt(100, 0)})
+	assert.False(t, hit)
+}
+
+func TestRaycast(t *testing.T) {
+	shapes := []Raycastable{
+		Circle{IPt(100, 0), I(20)},
+		&Rectangle{IPt(60, -10), IPt(70, 10)},
+		Square{IPt(30, 30), I(10)},
+	}
+
+	hit, h := Raycast(Line{IPt(0, 0), IPt(200, 0)}, shapes)
+	assert.True(t, hit)
+	assert.Equal(t, RaycastHit{IPt(60, 0), IPt(-1, 0), 1}, h)
+
+	ray := Line{IPt(0, 20), IPt(60, 50)}
+	hit, h = Raycast(ray, shapes)
+	assert.True(t, hit)
+	assert.Equal(t, IPt(25, 32), h.Pt)
+	assert.Equal(t, 2, h.ShapeIdx)
+
+	// Bounce off the surface.
+	dir := ray.Start.To(ray.End)
+	dir.Reflect(h.Normal)
+	assert.Equal(t, IPt(-60, 30), dir)
+
+	hit, _ = Raycast(Line{IPt(0, 0), IPt(0, 100)}, shapes)
+	assert.False(t, hit)
+}
@@ -0,0 +1,112 @@
+package gamelib
+
+// Region describes a group of connected positions, as found by LabelRegions.
+type Region struct {
+	// Number of positions in the region.
+	Size Int
+	// Smallest rectangle that contains all the positions of the region.
+	// Corner1 is the minimum and Corner2 is the maximum, both inclusive.
+	Bounds Rectangle
+	// Average of the positions of the region, rounded down. For regions that
+	// are not convex, this may fall outside the region.
+	Centroid Pt
+}
+
+// LabelRegions splits the matrix into regions of connected positions that
+// have the same value. Neighbors are defined by dirs, usually Directions4()
+// or Directions8(). The label of each position is the index of its region.
+func LabelRegions[T comparable](m Matrix[T], dirs []Pt) (labels Matrix[int], regions []Region) {
+	return labelRegions(m.Size(), dirs,
+		func(i int) bool { return true },
+		func(i, j int) bool { return m.cells[i] == m.cells[j] })
+}
+
+// LabelRegions splits the positions that are true into regions of connected
+// positions. Neighbors are defined by dirs, usually Directions4() or
+// Directions8(). The label of each true position is the index of its region.
+// The label of each false position is -1.
+func (m *MatBool) LabelRegions(dirs []Pt) (labels Matrix[int], regions []Region) {
+	return labelRegions(m.Size(), dirs,
+		func(i int) bool { return m.words[i/wordBits]&(1<<(i%wordBits)) != 0 },
+		func(i, j int) bool { return true })
+}
+
+// labelRegions labels the positions for which include returns true. Two
+// neighbors end up in the same region if same returns true for them.
+func labelRegions(size Pt, dirs []Pt, include func(i int) bool,
+	same func(i, j int) bool) (labels Matrix[int], regions []Region) {
+	labels = NewMatrix[int](size)
+	for i := range labels.cells {
+		labels.cells[i] = -1
+	}
+
+	queue := make([]int, 0, len(labels.cells))
+	for start := range labels.cells {
+		if labels.cells[start] >= 0 || !include(start) {
+			continue
+		}
+
+		// Flood fill a new region.
+		label := len(regions)
+		labels.cells[start] = label
+		queue = append(queue[:0], start)
+		startPt := labels.IndexToPt(I(start))
+		minPt, maxPt, sum := startPt, startPt, Pt{}
+		for idx := 0; idx < len(queue); idx++ {
+			pt := labels.IndexToPt(I(queue[idx]))
+			minPt = Pt{Min(minPt.X, pt.X), Min(minPt.Y, pt.Y)}
+			maxPt = Pt{Max(maxPt.X, pt.X), Max(maxPt.Y, pt.Y)}
+			sum.Add(pt)
+			for _, d := range dirs {
+				neighbor := pt.Plus(d)
+				if !labels.InBounds(neighbor) {
+					continue
+				}
+				n := labels.PtToIndex(neighbor).ToInt()
+				if labels.cells[n] < 0 && include(n) && same(start, n) {
+					labels.cells[n] = label
+					queue = append(queue, n)
+				}
+			}
+		}
+
+		var r Region
+		r.Size = I(len(queue))
+		r.Bounds = Rectangle{minPt, maxPt}
+		r.Centroid = sum.DivBy(r.Size)
+		regions = append(regions, r)
+	}
+	return
+}
+
+// LargestRegion returns the index of the region with the most positions, or
+// -1 if there are no regions. If several regions have the same size, the
+// first one wins.
+func LargestRegion(regions []Region) int {
+	largest := -1
+	for i := range regions {
+		if largest < 0 || regions[i].Size.Gt(regions[largest].Size) {
+			largest = i
+		}
+	}
+	return largest
+}
+
+// AllConnected returns true if all the positions that are true in targets are
+// connected to start, through positions that have the same value as start.
+// Neighbors are defined by dirs, as in LabelRegions.
+func (m *MatBool) AllConnected(start Pt, targets MatBool, dirs []Pt) bool {
+	same := m.Clone()
+	if !same.Get(start) {
+		same.Negate()
+	}
+	labels, _ := same.LabelRegions(dirs)
+	label := labels.Get(start)
+	connected := true
+	targets.ForEach(func(pt Pt) {
+		if labels.Get(pt) != label {
+			connected = false
+		}
+	})
+	return connected
+}
@@ -0,0 +1,101 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatBool_LabelRegions(t *testing.T) {
+	m := matBoolFromString(`
+xx----x
+xx---xx
+------x
+-x-----
+`)
+	labels, regions := m.LabelRegions(Directions4())
+	assert.Equal(t, 3, len(regions))
+
+	assert.Equal(t, 0, labels.Get(IPt(1, 1)))
+	assert.Equal(t, 1, labels.Get(IPt(5, 1)))
+	assert.Equal(t, 2, labels.Get(IPt(1, 3)))
+	assert.Equal(t, -1, labels.Get(IPt(3, 3)))
+
+	assert.Equal(t, I(4), regions[0].Size)
+	assert.Equal(t, Rectangle{IPt(0, 0), IPt(1, 1)}, regions[0].Bounds)
+	assert.Equal(t, IPt(0, 0), regions[0].Centroid)
+
+	assert.Equal(t, I(4), regions[1].Size)
+	assert.Equal(t, Rectangle{IPt(5, 0), IPt(6, 2)}, regions[1].Bounds)
+	assert.Equal(t, IPt(5, 1), regions[1].Centroid)
+
+	assert.Equal(t, I(1), regions[2].Size)
+	assert.Equal(t, IPt(1, 3), regions[2].Centroid)
+
+	assert.Equal(t, 0, LargestRegion(regions))
+	assert.Equal(t, -1, LargestRegion(nil))
+
+	// With diagonals, the point at (1, 3) is still alone but nothing else
+	// changes.
+	_, regions = m.LabelRegions(Directions8())
+	assert.Equal(t, 3, len(regions))
+}
+
+func TestLabelRegions(t *testing.T) {
+	m := MatrixFromString(`
+aab
+abb
+ccc
+`, map[byte]int{'a': 1, 'b': 2, 'c': 3})
+	labels, regions := LabelRegions(m, Directions4())
+	assert.Equal(t, 3, len(regions))
+	assert.Equal(t, labels.Get(IPt(0, 0)), labels.Get(IPt(0, 1)))
+	assert.Equal(t, labels.Get(IPt(2, 0)), labels.Get(IPt(1, 1)))
+	assert.NotEqual(t, labels.Get(IPt(0, 0)), labels.Get(IPt(2, 0)))
+	assert.Equal(t, I(3), regions[labels.Get(IPt(0, 2))].Size)
+
+	// With diagonals, a and b are still separate, because they have
+	// different values.
+	_, regions = LabelRegions(m, Directions8())
+	assert.Equal(t, 3, len(regions))
+}
+
+func TestMatBool_AllConnected(t *testing.T) {
+	walls := matBoolFromString(`
+---x---
+---x---
+---x---
+`)
+	food := matBoolFromString(`
+x------
+-------
+--x----
+`)
+	assert.True(t, walls.AllConnected(IPt(0, 2), food, Directions4()))
+
+	food.Set(IPt(6, 0))
+	assert.False(t, walls.AllConnected(IPt(0, 2), food, Directions4()))
+}
+
+func TestMatBool_AllConnected_Diagonals(t *testing.T) {
+	walls := matBoolFromString(`
+-x-
+x--
+---
+`)
+	food := matBoolFromString(`
+--x
+---
+---
+`)
+	// The only way from the corner is between the two walls.
+	assert.False(t, walls.AllConnected(IPt(0, 0), food, Directions4()))
+	assert.True(t, walls.AllConnected(IPt(0, 0), food, Directions8()))
+	// Starting on a wall follows the walls.
+	food = matBoolFromString(`
+---
+x--
+---
+`)
+	assert.False(t, walls.AllConnected(IPt(1, 0), food, Directions4()))
+	assert.True(t, walls.AllConnected(IPt(1, 0), food, Directions8()))
+}
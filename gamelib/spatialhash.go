@@ -0,0 +1,143 @@
+package gamelib
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SpatialHash finds entities that are near a position or inside an area
+// without checking all of them. The world is split into square cells and each
+// entity is listed in every cell that its bounding rectangle touches. A query
+// only looks at the entities listed in the cells that it touches.
+// Entities are identified by ids chosen by the caller. Queries return ids in
+// increasing order, so the results don't depend on the order in which
+// entities were inserted or moved.
+type SpatialHash struct {
+	cellSize Int
+	cells    map[Pt][]int
+	bounds   map[int]Rectangle
+}
+
+// NewSpatialHash creates an empty hash. cellSize should be a bit larger than
+// the typical entity, so that most entities touch only a few cells.
+func NewSpatialHash(cellSize Int) (h SpatialHash) {
+	if !cellSize.IsPositive() {
+		Check(fmt.Errorf("cell size must be positive, got %d", cellSize.ToInt64()))
+	}
+	h.cellSize = cellSize
+	h.cells = map[Pt][]int{}
+	h.bounds = map[int]Rectangle{}
+	return
+}
+
+// Len returns the number of entities in the hash.
+func (h *SpatialHash) Len() int {
+	return len(h.bounds)
+}
+
+// Bounds returns the bounding rectangle of an entity.
+func (h *SpatialHash) Bounds(id int) (bool, Rectangle) {
+	r, ok := h.bounds[id]
+	return ok, r
+}
+
+// cellRange returns the first and last cell touched by r, inclusive.
+func (h *SpatialHash) cellRange(r Rectangle) (minCell Pt, maxCell Pt) {
+	minCell.X, _ = floorDivMod(r.Min().X, h.cellSize)
+	minCell.Y, _ = floorDivMod(r.Min().Y, h.cellSize)
+	maxCell.X, _ = floorDivMod(r.Max().X, h.cellSize)
+	maxCell.Y, _ = floorDivMod(r.Max().Y, h.cellSize)
+	return
+}
+
+func (h *SpatialHash) forEachCell(r Rectangle, f func(cell Pt)) {
+	minCell, maxCell := h.cellRange(r)
+	for y := minCell.Y; y.Leq(maxCell.Y); y.Inc() {
+		for x := minCell.X; x.Leq(maxCell.X); x.Inc() {
+			f(Pt{x, y})
+		}
+	}
+}
+
+// Insert adds an entity. It crashes if the id is already in the hash.
+func (h *SpatialHash) Insert(id int, bounds Rectangle) {
+	if _, ok := h.bounds[id]; ok {
+		Check(fmt.Errorf("entity %d is already in the spatial hash", id))
+	}
+	h.bounds[id] = bounds
+	h.forEachCell(bounds, func(cell Pt) {
+		h.cells[cell] = append(h.cells[cell], id)
+	})
+}
+
+// Remove takes out an entity. It crashes if the id is not in the hash.
+func (h *SpatialHash) Remove(id int) {
+	bounds, ok := h.bounds[id]
+	if !ok {
+		Check(fmt.Errorf("entity %d is not in the spatial hash", id))
+	}
+	delete(h.bounds, id)
+	h.forEachCell(bounds, func(cell Pt) {
+		ids := h.cells[cell]
+		i := slices.Index(ids, id)
+		ids = slices.Delete(ids, i, i+1)
+		if len(ids) == 0 {
+			delete(h.cells, cell)
+		} else {
+			h.cells[cell] = ids
+		}
+	})
+}
+
+// Move changes the bounding rectangle of an entity. It crashes if the id is
+// not in the hash.
+func (h *SpatialHash) Move(id int, bounds Rectangle) {
+	old, ok := h.bounds[id]
+	if !ok {
+		Check(fmt.Errorf("entity %d is not in the spatial hash", id))
+	}
+	// Most moves are small and don't change the cells.
+	oldMin, oldMax := h.cellRange(old)
+	newMin, newMax := h.cellRange(bounds)
+	if oldMin == newMin && oldMax == newMax {
+		h.bounds[id] = bounds
+		return
+	}
+	h.Remove(id)
+	h.Insert(id, bounds)
+}
+
+// candidates returns the ids listed in the cells touched by r, sorted and
+// without duplicates. Their bounds may not actually touch r.
+func (h *SpatialHash) candidates(r Rectangle) (ids []int) {
+	h.forEachCell(r, func(cell Pt) {
+		ids = append(ids, h.cells[cell]...)
+	})
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// QueryRect returns the ids of the entities whose bounds have at least one
+// point in common with r, in increasing order.
+func (h *SpatialHash) QueryRect(r Rectangle) (ids []int) {
+	for _, id := range h.candidates(r) {
+		if b := h.bounds[id]; b.Overlaps(r) {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+// QueryRadius returns the ids of the entities whose bounds have at least one
+// point at a distance of at most radius from center, in increasing order.
+func (h *SpatialHash) QueryRadius(center Pt, radius Int) (ids []int) {
+	circle := Circle{center, radius.Times(TWO)}
+	r := Rectangle{center, center}
+	r = r.Expand(radius)
+	for _, id := range h.candidates(r) {
+		if CircleRectangleOverlap(circle, h.bounds[id]) {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
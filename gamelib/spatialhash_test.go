@@ -0,0 +1,80 @@
+package gamelib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSpatialHash(t *testing.T) {
+	h := NewSpatialHash(I(10))
+	h.Insert(3, Rectangle{IPt(0, 0), IPt(5, 5)})
+	h.Insert(1, Rectangle{IPt(8, 8), IPt(25, 12)})
+	h.Insert(2, Rectangle{IPt(-30, -30), IPt(-25, -25)})
+	assert.Equal(t, 3, h.Len())
+
+	assert.Equal(t, []int{1, 3}, h.QueryRect(Rectangle{IPt(0, 0), IPt(10, 10)}))
+	assert.Equal(t, []int{1}, h.QueryRect(Rectangle{IPt(20, 10), IPt(21, 11)}))
+	assert.Equal(t, []int{2}, h.QueryRect(Rectangle{IPt(-26, -26), IPt(-26, -26)}))
+	assert.Empty(t, h.QueryRect(Rectangle{IPt(6, 6), IPt(7, 7)}))
+
+	assert.Equal(t, []int{3}, h.QueryRadius(IPt(8, 0), I(3)))
+	assert.Equal(t, []int{1, 3}, h.QueryRadius(IPt(8, 0), I(8)))
+	// Close to both rectangles, but not touching them.
+	assert.Empty(t, h.QueryRadius(IPt(1, 18), I(6)))
+
+	h.Move(3, Rectangle{IPt(100, 100), IPt(105, 105)})
+	assert.Equal(t, []int{1}, h.QueryRect(Rectangle{IPt(0, 0), IPt(10, 10)}))
+	assert.Equal(t, []int{3}, h.QueryRadius(IPt(100, 100), I(1)))
+	ok, r := h.Bounds(3)
+	assert.True(t, ok)
+	assert.Equal(t, Rectangle{IPt(100, 100), IPt(105, 105)}, r)
+
+	h.Remove(1)
+	assert.Empty(t, h.QueryRect(Rectangle{IPt(0, 0), IPt(30, 30)}))
+	assert.Equal(t, 2, h.Len())
+	ok, _ = h.Bounds(1)
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { h.Insert(2, Rectangle{}) })
+	assert.Panics(t, func() { h.Remove(1) })
+}
+
+func TestSpatialHash_AgreesWithBruteForce(t *testing.T) {
+	RSeed(I(5))
+	h := NewSpatialHash(I(20))
+	bounds := map[int]Rectangle{}
+	randomRect := func() Rectangle {
+		c := Pt{RInt(I(-100), I(100)), RInt(I(-100), I(100))}
+		return Rectangle{c, c.Plus(Pt{RInt(I(0), I(30)), RInt(I(0), I(30))})}
+	}
+	for id := 0; id < 50; id++ {
+		bounds[id] = randomRect()
+		h.Insert(id, bounds[id])
+	}
+	for id := 0; id < 50; id += 3 {
+		bounds[id] = randomRect()
+		h.Move(id, bounds[id])
+	}
+
+	for i := 0; i < 20; i++ {
+		q := randomRect()
+		var expected []int
+		for id := 0; id < 50; id++ {
+			b := bounds[id]
+			if ok, _ := b.Intersect(q); ok {
+				expected = append(expected, id)
+			}
+		}
+		assert.Equal(t, expected, h.QueryRect(q))
+
+		center := q.Corner1
+		radius := RInt(I(0), I(40))
+		expected = nil
+		for id := 0; id < 50; id++ {
+			if CircleRectangleOverlap(Circle{center, radius.Times(TWO)}, bounds[id]) {
+				expected = append(expected, id)
+			}
+		}
+		assert.Equal(t, expected, h.QueryRadius(center, radius))
+	}
+}
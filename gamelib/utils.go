@@ -1,31 +1,21 @@
 package gamelib
 
 import (
-	"archive/zip"
 	"bytes"
 	"crypto/sha256"
-	"database/sql"
-	"embed"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"github.com/go-sql-driver/mysql"
-	"github.com/google/uuid"
-	"github.com/hajimehoshi/ebiten/v2"
 	"image"
 	"image/color"
+	"image/png"
 	"io"
-	"io/fs"
 	"math"
-	"mime/multipart"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"slices"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -230,68 +220,28 @@ func Home(relativePath string) string {
 	return path.Join(HomeFolder(), relativePath)
 }
 
+// Unzip returns the contents of an archive written by Zip. It expects exactly
+// one entry in the archive.
 func Unzip(data []byte) []byte {
-	// Get a bytes.Reader, which implements the io.ReaderAt interface required
-	// by the zip.NewReader() function.
-	bytesReader := bytes.NewReader(data)
-
-	// Open a zip archive for reading.
-	r, err := zip.NewReader(bytesReader, int64(len(data)))
-	Check(err)
-
-	// We assume there's exactly 1 file in the zip archive.
-	if len(r.File) != 1 {
-		Check(errors.New(fmt.Sprintf("expected exactly one file in zip archive, got: %d", len(r.File))))
+	a := NewArchiveReaderFromBytes(data)
+	names := a.Names()
+	if len(names) != 1 {
+		Check(fmt.Errorf("expected exactly one file in zip archive, got: %d", len(names)))
 	}
-
-	// Get a reader for that 1 file.
-	f := r.File[0]
-	rc, err := f.Open()
-	Check(err)
-	defer func(rc io.ReadCloser) { Check(rc.Close()) }(rc)
-
-	// Keep reading bytes, 1024 bytes at a time.
-	buffer := make([]byte, 1024)
-	fullContent := make([]byte, 0, 1024)
-	for {
-		nbytesActuallyRead, err := rc.Read(buffer)
-		fullContent = append(fullContent, buffer[:nbytesActuallyRead]...)
-		if err == io.EOF {
-			break
-		}
-		Check(err)
-		if nbytesActuallyRead == 0 {
-			break
-		}
-	}
-
-	// Return bytes.
-	return fullContent
+	return a.Read(names[0])
 }
 
 func UnzipFromFile(filename string) []byte {
 	return Unzip(ReadFile(filename))
 }
 
+// Zip puts data in an archive with a single entry called "recorded-inputs".
+// Use ArchiveWriter for archives with more entries.
 func Zip(data []byte) []byte {
-	// Create a buffer to write our archive to.
 	buf := new(bytes.Buffer)
-
-	// Create a new zip archive.
-	w := zip.NewWriter(buf)
-
-	// Create a single file inside it called "recorded-inputs".
-	f, err := w.Create("recorded-inputs")
-	Check(err)
-
-	// Write/compress the data to the file inside the zip.
-	_, err = f.Write(data)
-	Check(err)
-
-	// Make sure to check the error on Close.
-	err = w.Close()
-	Check(err)
-
+	a := NewArchiveWriter(buf)
+	a.Add("recorded-inputs", data)
+	a.Close()
 	return buf.Bytes()
 }
 
@@ -300,34 +250,6 @@ func ZipToFile(filename string, data []byte) {
 	WriteFile(filename, Zip(data))
 }
 
-func LoadImage(str string) *ebiten.Image {
-	file, err := os.Open(str)
-	defer func(file *os.File) { Check(file.Close()) }(file)
-	Check(err)
-
-	img, _, err := image.Decode(file)
-	Check(err)
-	if err != nil {
-		return nil
-	}
-
-	return ebiten.NewImageFromImage(img)
-}
-
-func LoadImageEmbedded(str string, efs *embed.FS) *ebiten.Image {
-	file, err := efs.Open(str)
-	defer func(file fs.File) { Check(file.Close()) }(file)
-	Check(err)
-
-	img, _, err := image.Decode(file)
-	Check(err)
-	if err != nil {
-		return nil
-	}
-
-	return ebiten.NewImageFromImage(img)
-}
-
 func EqualFloats(f1, f2 float64) bool {
 	return math.Abs(f1-f2) < 0.000001
 }
@@ -354,113 +276,12 @@ func Remove[S ~[]E, E any](s S, i int) S {
 	return s[:len(s)-1]
 }
 
-func ComputeSpriteMask(img *ebiten.Image) *ebiten.Image {
-	mask := ebiten.NewImageFromImage(img)
-	sz := mask.Bounds().Size()
-	for y := 0; y < sz.Y; y++ {
-		for x := 0; x < sz.X; x++ {
-			_, _, _, a := img.At(x, y).RGBA()
-			if a > 0 {
-				mask.Set(x, y, color.RGBA{0, 0, 0, 255})
-			}
-		}
-	}
-	return mask
-}
-
-func sendDataToDbHttp(user string, version int64, id uuid.UUID, data []byte) {
-	url := "https://playful-patterns.com/submit-playthrough.php"
-
-	// Create a buffer to write our multipart form data.
-	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
-	err := writer.WriteField("user", user)
-	Check(err)
-	err = writer.WriteField("version", strconv.FormatInt(version, 10))
-	Check(err)
-	err = writer.WriteField("id", id.String())
-	Check(err)
-	if data != nil {
-		part, err := writer.CreateFormFile("playthrough", "rima")
-		Check(err)
-		_, err = part.Write(data)
-		Check(err)
-	}
-	err = writer.Close()
-	Check(err)
-
-	// Create a POST request with the multipart form data.
-	request, err := http.NewRequest("POST", url, &requestBody)
-	Check(err)
-	request.Header.Set("content-type", writer.FormDataContentType())
-
-	// Perform the request.
-	client := &http.Client{}
-	response, err := client.Do(request)
-	Check(err)
-	if response.StatusCode != 200 {
-		Check(fmt.Errorf("http request failed: %d", response.StatusCode))
-	}
-}
-
-func InitializeIdInDbHttp(user string, version int64, id uuid.UUID) {
-	sendDataToDbHttp(user, version, id, nil)
-}
-
-func UploadDataToDbHttp(user string, version int64, id uuid.UUID, data []byte) {
-	sendDataToDbHttp(user, version, id, data)
-}
-
-func ConnectToDbSql() *sql.DB {
-	cfg := mysql.Config{
-		User:                 os.Getenv("MILN_DBUSER"),
-		Passwd:               os.Getenv("MILN_DBPASSWORD"),
-		Net:                  "tcp",
-		Addr:                 os.Getenv("MILN_DBADDR"),
-		DBName:               os.Getenv("MILN_DBNAME"),
-		AllowNativePasswords: true,
-		ParseTime:            true,
-	}
-
-	db, err := sql.Open("mysql", cfg.FormatDSN())
-	Check(err)
-	err = db.Ping()
-	Check(err)
-	return db
-}
-
-func InitializeIdInDbSql(db *sql.DB, id uuid.UUID) {
-	_, err := db.Exec("INSERT INTO playthroughs (id) VALUES (?)", id.String())
-	Check(err)
-}
-
-func UploadDataToDbSql(db *sql.DB, id uuid.UUID, data []byte) {
-	_, err := db.Exec("UPDATE playthroughs SET playthrough = ? WHERE id = ?", data, id.String())
-	Check(err)
-}
-
-func DownloadDataFromDbSql(db *sql.DB, id uuid.UUID) (data []byte) {
-	rows, err := db.Query("SELECT playthrough FROM playthroughs WHERE id = ?", id.String())
-	Check(err)
-	defer func(rows *sql.Rows) { Check(rows.Close()) }(rows)
-	if !rows.Next() {
-		Check(fmt.Errorf("id not found: %s", id.String()))
-	}
-	err = rows.Scan(&data)
-	Check(err)
-	return
-}
-
-func InspectDataFromDbSql(db *sql.DB) {
-	rows, err := db.Query("SELECT * FROM playthroughs")
-	Check(err)
-	defer func(rows *sql.Rows) { Check(rows.Close()) }(rows)
-
-	for rows.Next() {
-		var data []byte
-		err := rows.Scan(&data)
-		Check(err)
-		println(len(data))
+func Directions4() []Pt {
+	return []Pt{
+		{I(1).Negative(), I(0)},
+		{I(1), I(0)},
+		{I(0), I(1).Negative()},
+		{I(0), I(1)},
 	}
 }
 
@@ -517,6 +338,72 @@ func MatrixFromString[T comparable](str string, vals map[byte]T) (m Matrix[T]) {
 	return
 }
 
+// MatrixToString is the reverse of MatrixFromString. Values that are not in
+// vals are written as '?'.
+func MatrixToString[T comparable](m Matrix[T], vals map[T]byte) string {
+	var sb strings.Builder
+	for y := I(0); y.Lt(m.size.Y); y.Inc() {
+		sb.WriteByte('\n')
+		for x := I(0); x.Lt(m.size.X); x.Inc() {
+			if c, ok := vals[m.Get(Pt{x, y})]; ok {
+				sb.WriteByte(c)
+			} else {
+				sb.WriteByte('?')
+			}
+		}
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// MatrixFromImage creates a matrix with the same size as img, where each
+// element gets the value of the color of the corresponding pixel. Colors that
+// are not in vals are ignored, the same as unknown characters in
+// MatrixFromString.
+func MatrixFromImage[T comparable](img image.Image, vals map[color.Color]T) (m Matrix[T]) {
+	// Colors that look the same can come from different color models (e.g.
+	// color.RGBA and color.NRGBA), so compare them in a single model.
+	nrgbaVals := map[color.NRGBA]T{}
+	for c, v := range vals {
+		nrgbaVals[color.NRGBAModel.Convert(c).(color.NRGBA)] = v
+	}
+
+	b := img.Bounds()
+	m = NewMatrix[T](IPt(b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y))
+			if val, ok := nrgbaVals[c.(color.NRGBA)]; ok {
+				m.Set(IPt(x, y), val)
+			}
+		}
+	}
+	return
+}
+
+// MatrixToImage is the reverse of MatrixFromImage. Values that are not in
+// vals become transparent pixels.
+func MatrixToImage[T comparable](m Matrix[T], vals map[T]color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, m.size.X.ToInt(), m.size.Y.ToInt()))
+	for y := I(0); y.Lt(m.size.Y); y.Inc() {
+		for x := I(0); x.Lt(m.size.X); x.Inc() {
+			if c, ok := vals[m.Get(Pt{x, y})]; ok {
+				img.Set(x.ToInt(), y.ToInt(), c)
+			}
+		}
+	}
+	return img
+}
+
+// SaveImage writes img to a PNG file. This is mostly useful for looking at
+// matrices while debugging.
+func SaveImage(filename string, img image.Image) {
+	file, err := os.Create(filename)
+	Check(err)
+	defer CloseFile(file)
+	Check(png.Encode(file, img))
+}
+
 // HashBytes receives a byte array and returns its SHA-256 hash as a hex string.
 func HashBytes(input []byte) string {
 	// Create a new SHA-256 hash
@@ -1,8 +1,10 @@
 package gamelib
 
 import (
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"strings"
 	"testing"
 )
 
@@ -41,49 +43,68 @@ func TestMatrixFromString(t *testing.T) {
 }
 
 func TestConnectedPositions(t *testing.T) {
-	var m1 MatBool
-	m1.Matrix = MatrixFromString(`
+	m1 := MatBoolFromMatrix(MatrixFromString(`
 x---x--
 --x-xx-
 --x----
-`, map[byte]bool{'x': true})
+`, map[byte]bool{'x': true}))
 
-	var expected1 MatBool
-	expected1.Matrix = MatrixFromString(`
-x---x--
---x-xx-
---x----
-`, map[byte]bool{'x': true, '-': false})
+	// All the free positions touch each other, at least diagonally.
+	expected1 := MatBoolFromMatrix(MatrixFromString(`
+-xxx-xx
+xx-x--x
+xx-xxxx
+`, map[byte]bool{'x': true, '-': false}))
 
 	result1 := m1.ConnectedPositions(IPt(1, 0))
 	assert.Equal(t, expected1, result1)
 
-	var expected2 MatBool
-	expected2.Matrix = MatrixFromString(`
-x---a--
---x-aa-
---x----
-`, map[byte]bool{'x': true, 'a': false})
+	expected2 := MatBoolFromMatrix(MatrixFromString(`
+----x--
+----xx-
+-------
+`, map[byte]bool{'x': true, '-': false}))
 	result2 := m1.ConnectedPositions(IPt(5, 1))
 	assert.Equal(t, expected2, result2)
 }
 
-func TestDbSql(t *testing.T) {
-	db := ConnectToDbSql()
-	id := uuid.New()
-	InitializeIdInDbSql(db, id)
-	UploadDataToDbSql(db, id, []byte("what do you mean"))
-	InspectDataFromDbSql(db)
-	assert.True(t, true)
+func TestMatrix_ToString(t *testing.T) {
+	str := `
+----x--
+--x-y--
+-------
+`
+	m := MatrixFromString(str, map[byte]Int{'x': ONE, 'y': TWO})
+	assert.Equal(t, str, MatrixToString(m, map[Int]byte{ZERO: '-', ONE: 'x', TWO: 'y'}))
+	assert.Equal(t, strings.ReplaceAll(str, "y", "?"),
+		MatrixToString(m, map[Int]byte{ZERO: '-', ONE: 'x'}))
 }
 
-func TestDbHttp(t *testing.T) {
-	id := uuid.New()
-	// id, err := uuid.Parse("550e8400-e29b-41d4-a716-446655440002")
-	// Check(err)
-	InitializeIdInDbHttp("test-user", 19, id)
-	UploadDataToDbHttp("test-user", 19, id, []byte("mele 1"))
-	UploadDataToDbHttp("test-user", 19, id, []byte("mele 2"))
-	UploadDataToDbHttp("test-user", 19, id, []byte("mele totusi, da -------"))
-	assert.Equal(t, true, true)
+func TestMatrix_ToImage(t *testing.T) {
+	m := MatrixFromString(`
+x--
+-xo
+`, map[byte]Int{'x': ONE, 'o': TWO})
+	colors := map[Int]color.Color{
+		ZERO: color.RGBA{0, 0, 0, 255},
+		ONE:  color.RGBA{255, 0, 0, 255},
+		TWO:  color.NRGBA{0, 0, 255, 255},
+	}
+	img := MatrixToImage(m, colors)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), img.Bounds())
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, img.At(1, 1))
+
+	// Going through the image gets us back the same matrix, even if the keys
+	// use different color models.
+	vals := map[color.Color]Int{}
+	for k, v := range colors {
+		vals[v] = k
+	}
+	assert.Equal(t, m, MatrixFromImage(img, vals))
+
+	// Unknown colors are ignored.
+	delete(vals, colors[TWO])
+	expected := m.Clone()
+	expected.Set(IPt(2, 1), ZERO)
+	assert.Equal(t, expected, MatrixFromImage(img, vals))
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/text"
 	. "github.com/marisvali/vlok/ai"
 	. "github.com/marisvali/vlok/gamelib"
+	. "github.com/marisvali/vlok/gamelib/gfx"
 	. "github.com/marisvali/vlok/world"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/gofont/goregular"
@@ -18,6 +19,7 @@ import (
 	"image/color"
 	_ "image/png"
 	"slices"
+	"time"
 )
 
 var BlockSize = I(80)
@@ -33,6 +35,7 @@ type Gui struct {
 	imgRoom            *ebiten.Image
 	imgTextBackground  *ebiten.Image
 	imgTextColor       *ebiten.Image
+	imgBlocked         *ebiten.Image
 	world              World
 	frameIdx           Int
 	folderWatcher      FolderWatcher
@@ -48,13 +51,13 @@ type Gui struct {
 	mousePt            Pt           // mouse position in this frame
 	username           string
 	ai                 AI
-}
-
-type uploadData struct {
-	user        string
-	version     int64
-	id          uuid.UUID
-	playthrough []byte
+	playthrough        Playthrough
+	metadata           Metadata
+	stateHashes        []uint64 // hash of the world after each frame
+	crashErr           error    // set when Draw crashes, returned by Update
+	// Hashing the world every frame is slow, so it's only done when the
+	// level will be saved as a recording.
+	hashStates bool
 }
 
 func (g *Gui) JustPressed(key ebiten.Key) bool {
@@ -80,7 +83,12 @@ func (g *Gui) UserRequestedRestartLevel() bool {
 	return g.JustPressed(ebiten.KeyR) || g.JustClicked(g.buttonRestartLevel)
 }
 
-func (g *Gui) Update() error {
+func (g *Gui) Update() (err error) {
+	defer g.recoverCrash(&err)
+	if g.crashErr != nil {
+		return g.crashErr
+	}
+
 	// Get input once, so we don't need to get it every time we need it in
 	// other functions.
 	g.justPressedKeys = g.justPressedKeys[:0]
@@ -89,9 +97,20 @@ func (g *Gui) Update() error {
 	g.mousePt = IPt(x, y)
 
 	if g.JustPressed(ebiten.KeyX) {
+		g.saveRecording(OutcomeQuit)
 		return ebiten.Termination
 	}
 
+	if g.UserRequestedNewLevel() {
+		g.saveRecording(OutcomeNewLevel)
+		g.setWorld(NewWorld(RInt(I(0), I(1000000))))
+	}
+
+	if g.UserRequestedRestartLevel() {
+		g.saveRecording(OutcomeRestarted)
+		g.setWorld(NewWorld(g.world.Seed))
+	}
+
 	var input PlayerInput
 	input.Position = g.ScreenToWorldPos(g.mousePt)
 	input.Pick = inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0)
@@ -103,7 +122,13 @@ func (g *Gui) Update() error {
 	input.MoveToFood = g.JustPressed(ebiten.KeyF)
 
 	// input = g.ai.Step(&g.world)
+	// Record the input before using it, so that if Step crashes, the
+	// recording has the input that crashed it.
+	g.playthrough.History = append(g.playthrough.History, input)
 	g.world.Step(input)
+	if g.hashStates {
+		g.stateHashes = append(g.stateHashes, HashState(g.world.State()))
+	}
 
 	if g.folderWatcher.FolderContentsChanged() {
 		g.loadGuiData()
@@ -113,6 +138,26 @@ func (g *Gui) Update() error {
 	return nil
 }
 
+func (g *Gui) setWorld(w World) {
+	g.world = w
+	g.playthrough = NewPlaythrough(w.Seed)
+	g.stateHashes = g.stateHashes[:0]
+	g.hashStates = FileExists("recordings")
+	g.metadata = Metadata{}
+	g.metadata.User = g.username
+	g.metadata.Version = Version
+	g.metadata.Id = uuid.New().String()
+	g.metadata.Platform = getPlatform()
+	g.metadata.Seed = w.Seed.ToInt64()
+	g.metadata.StartTime = time.Now()
+
+	// Show the blocked parts of the room as a semi-transparent layer on top
+	// of the room image.
+	room := g.world.Room.ToMatrix()
+	img := MatrixToImage(room, map[bool]color.Color{true: color.RGBA{40, 40, 40, 200}})
+	g.imgBlocked = ebiten.NewImageFromImage(img)
+}
+
 func (g *Gui) ScreenToWorldPos(screenPos Pt) (worldPos Pt) {
 	// worldPos = (screenPos - guiMargin) * (world.Size / playSize)
 	playPos := screenPos.Minus(Pt{g.guiMargin, g.guiMargin})
@@ -164,6 +209,8 @@ func (g *Gui) DrawRect(screen *ebiten.Image, r Rectangle, col color.Color) {
 func (g *Gui) DrawPlayRegion(screen *ebiten.Image) {
 	g.DrawWorldSprite(screen, g.imgRoom,
 		g.world.Size.DivBy(I(2)), g.world.Size)
+	g.DrawWorldSprite(screen, g.imgBlocked,
+		g.world.Size.DivBy(I(2)), g.world.Size)
 	// g.DrawRect(screen, g.world.Character.MoveLimits, color.RGBA{255, 0, 0, 255})
 	g.DrawWorldSprite(screen, g.imgCharacter,
 		g.world.Character.Pos, g.world.Character.Size)
@@ -180,21 +227,25 @@ func (g *Gui) DrawPlayRegion(screen *ebiten.Image) {
 // worldPos indicates the center of img
 func (g *Gui) DrawWorldSprite(screen *ebiten.Image, img *ebiten.Image,
 	worldPos Pt, worldSize Pt) {
-	screenPosCenter := g.WorldToPlayRegionPos(worldPos)
-	screenSize := g.WorldToPlayRegionPos(worldSize)
-	screenPosUpperLeft := screenPosCenter.Minus(screenSize.DivBy(I(2)))
+	r := RectangleFromCenterSize(g.WorldToPlayRegionPos(worldPos),
+		g.WorldToPlayRegionPos(worldSize))
 	DrawSprite(screen, img,
-		screenPosUpperLeft.X.ToFloat64(), screenPosUpperLeft.Y.ToFloat64(),
-		screenSize.X.ToFloat64(), screenSize.Y.ToFloat64())
+		r.Min().X.ToFloat64(), r.Min().Y.ToFloat64(),
+		r.Width().ToFloat64(), r.Height().ToFloat64())
 }
 
 func (g *Gui) Draw(screen *ebiten.Image) {
+	defer g.recoverCrash(&g.crashErr)
+	if g.crashErr != nil {
+		return
+	}
+
 	screen.Fill(color.RGBA{0, 0, 0, 255})
 
 	{
-		upperLeft := Pt{g.guiMargin, g.guiMargin}
-		lowerRight := upperLeft.Plus(g.playSize)
-		playRegion := SubImage(screen, Rectangle{upperLeft, lowerRight})
+		r := Rectangle{Pt{}, g.playSize}
+		r = r.Translate(Pt{g.guiMargin, g.guiMargin})
+		playRegion := SubImage(screen, r)
 		g.DrawPlayRegion(playRegion)
 	}
 
@@ -267,6 +318,37 @@ func (g *Gui) DrawInstructionalText(screen *ebiten.Image) {
 	g.DrawText(textBox, message, true, g.imgTextColor.At(0, 0))
 }
 
+// saveRecording writes the playthrough of the current level and its metadata
+// to a new file in the recordings folder. Nothing is saved if the folder
+// doesn't exist, or if nothing was played yet.
+func (g *Gui) saveRecording(outcome Outcome) {
+	if len(g.playthrough.History) == 0 {
+		return
+	}
+	filename := GetNewRecordingFile()
+	if filename == "" {
+		return
+	}
+	a := CreateArchive(filename)
+	g.writeRecording(a, outcome)
+	a.Close()
+}
+
+// writeRecording adds the playthrough of the current level, its metadata and
+// its state hashes, if they were computed, to a. g.metadata stays as it is, so
+// that the level can still be saved again.
+func (g *Gui) writeRecording(a *ArchiveWriter, outcome Outcome) {
+	m := g.metadata
+	m.EndTime = time.Now()
+	m.Frames = int64(len(g.playthrough.History))
+	m.Outcome = outcome
+	m.SaveToArchive(a)
+	g.playthrough.SaveToArchive(a)
+	if g.hashStates {
+		SaveStateHashes(a, g.stateHashes)
+	}
+}
+
 func (g *Gui) DrawText(screen *ebiten.Image, message string, centerX bool, color color.Color) {
 	// Remember that with text there is an origin point for the text.
 	// That origin point is kind of the lower-left corner of the bounds of the
@@ -326,8 +408,9 @@ func (g *Gui) loadGuiData() {
 func main() {
 	var g Gui
 	g.username = getUsername()
+	uploadCrashes()
 
-	g.world = NewWorld()
+	g.setWorld(NewWorld(RInt(I(0), I(1000000))))
 	g.textHeight = I(75)
 	g.guiMargin = I(30)
 	g.buttonRegionWidth = I(200)
@@ -5,3 +5,7 @@ package main
 func getUsername() string {
 	return "vali-dev"
 }
+
+func getPlatform() string {
+	return "native"
+}
@@ -0,0 +1,202 @@
+// Package stress feeds random inputs to the game to find input sequences that
+// crash it.
+//
+// Int panics on overflow and Check panics on errors, so a bad sequence of
+// inputs can crash World.Step. Run plays many levels with random inputs and
+// returns the ones that crashed, as playthroughs that can be saved as
+// recordings. Minimize then removes as many inputs as possible while keeping
+// the crash, so that the bug is easier to find.
+package stress
+
+import (
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	. "github.com/marisvali/vlok/world"
+	"math/rand"
+	"runtime/debug"
+	"strings"
+)
+
+type Config struct {
+	// Version of the rules to test.
+	Version int64
+	// Seed of the random inputs. The levels are generated from Seed, Seed+1
+	// and so on.
+	Seed int64
+	// Number of levels to play.
+	Runs int
+	// Number of frames to play in each level.
+	Frames int
+	// Area in which the random mouse positions are picked. It should be
+	// larger than the world, as the mouse can be outside of it.
+	Area Rectangle
+}
+
+// Crash is a playthrough that crashes the game.
+type Crash struct {
+	Playthrough Playthrough
+	// Index of the input that crashed.
+	Frame int
+	// The value passed to panic and the stack trace at that point.
+	Message string
+	Stack   string
+	// State of the simulation when it crashed, as returned by its State
+	// method. It's nil if creating the level crashed.
+	State any
+}
+
+// sameAs returns true if c and other look like the same bug: they crash with
+// the same message, at the same place.
+func (c *Crash) sameAs(other Crash) bool {
+	return c.Message == other.Message &&
+		panicLocation(c.Stack) == panicLocation(other.Stack)
+}
+
+// panicLocation returns the file and line that called panic, from a stack
+// trace taken while recovering. Frames of the runtime and of Check are
+// skipped, as they are the same for most crashes.
+func panicLocation(stack string) string {
+	// After the header, each frame is a line with the function followed by
+	// a line with the file and line.
+	lines := strings.Split(stack, "\n")
+	afterPanic := false
+	for i := 1; i+1 < len(lines); i += 2 {
+		function := lines[i]
+		if strings.HasPrefix(function, "panic(") {
+			afterPanic = true
+			continue
+		}
+		if !afterPanic || strings.HasPrefix(function, "runtime.") ||
+			strings.HasPrefix(function, "github.com/marisvali/vlok/gamelib.Check(") {
+			continue
+		}
+		location := strings.TrimSpace(lines[i+1])
+		if j := strings.LastIndex(location, " +0x"); j >= 0 {
+			location = location[:j]
+		}
+		return location
+	}
+	return ""
+}
+
+// RandomInputs returns n random inputs. Players mostly move the mouse a bit
+// at a time and rarely click or press keys, so the inputs do the same, with
+// the occasional jump to a random position.
+func RandomInputs(r *rand.Rand, n int, area Rectangle) (inputs []PlayerInput) {
+	randomInt := func(lo, hi Int) Int {
+		return lo.Plus(I64(r.Int63n(hi.Minus(lo).ToInt64() + 1)))
+	}
+	randomPt := func() Pt {
+		return Pt{randomInt(area.Min().X, area.Max().X),
+			randomInt(area.Min().Y, area.Max().Y)}
+	}
+	chance := func(percent int) bool {
+		return r.Intn(100) < percent
+	}
+
+	var input PlayerInput
+	input.Position = randomPt()
+	for i := 0; i < n; i++ {
+		if chance(5) {
+			input.Position = randomPt()
+		} else {
+			step := U(20)
+			input.Position.Add(Pt{randomInt(step.Negative(), step),
+				randomInt(step.Negative(), step)})
+			input.Position = area.Clamp(input.Position)
+		}
+		input.Pick = chance(5)
+		input.Release = chance(5)
+		input.MoveLeft = chance(2)
+		input.MoveRight = chance(2)
+		input.MoveUp = chance(2)
+		input.MoveDown = chance(2)
+		input.MoveToFood = chance(2)
+		inputs = append(inputs, input)
+	}
+	return
+}
+
+// Replay plays p and returns the crash, if any.
+func Replay(p Playthrough) (crashed bool, c Crash) {
+	c.Playthrough = p
+	var s Simulation
+	defer func() {
+		if r := recover(); r != nil {
+			crashed = true
+			c.Message = fmt.Sprint(r)
+			c.Stack = string(debug.Stack())
+			if s != nil {
+				c.State = s.State()
+			}
+		}
+	}()
+
+	// Creating the level may crash too, in which case Frame stays -1.
+	c.Frame = -1
+	s, err := NewSimulation(p.Version, p.Seed)
+	Check(err)
+	for i, input := range p.History {
+		c.Frame = i
+		s.Step(input)
+	}
+	return false, Crash{}
+}
+
+// Run plays cfg.Runs levels with random inputs and returns the playthroughs
+// that crashed. The same cfg always gives the same results.
+func Run(cfg Config) (crashes []Crash) {
+	r := rand.New(rand.NewSource(cfg.Seed))
+	for i := 0; i < cfg.Runs; i++ {
+		var p Playthrough
+		p.Version = cfg.Version
+		p.Seed = I64(cfg.Seed + int64(i))
+		p.History = RandomInputs(r, cfg.Frames, cfg.Area)
+		if crashed, c := Replay(p); crashed {
+			crashes = append(crashes, c)
+		}
+	}
+	return
+}
+
+// Minimize removes inputs from the playthrough of c, as long as it still
+// crashes in the same way, and returns the crash of the smallest playthrough
+// it found. Removing inputs can lead to other crashes, which are ignored so
+// that the result still shows the original bug. It uses the ddmin algorithm:
+// try to remove chunks of the inputs, starting with halves and going down to
+// single inputs. The result is not guaranteed to be the smallest possible, but
+// removing any single input from it makes the crash go away.
+func Minimize(c Crash) Crash {
+	// Inputs after the crash are never played.
+	c.Playthrough.History = c.Playthrough.History[:c.Frame+1]
+
+	n := 2
+	for len(c.Playthrough.History) >= 2 {
+		history := c.Playthrough.History
+		chunkSize := (len(history) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(history); start += chunkSize {
+			end := min(start+chunkSize, len(history))
+			p := c.Playthrough
+			p.History = append(append([]PlayerInput{}, history[:start]...),
+				history[end:]...)
+			if crashed, newCrash := Replay(p); crashed && newCrash.sameAs(c) {
+				c = newCrash
+				c.Playthrough.History = p.History[:c.Frame+1]
+				reduced = true
+				break
+			}
+		}
+
+		if reduced {
+			// Fewer inputs are left, so try slightly bigger chunks
+			// again.
+			n = max(n-1, 2)
+		} else if chunkSize == 1 {
+			break
+		} else {
+			n = min(n*2, len(history))
+		}
+	}
+	return c
+}
@@ -0,0 +1,143 @@
+package stress
+
+import (
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	. "github.com/marisvali/vlok/world"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+// fragileVersion is the version of fragileSim, registered only for these
+// tests.
+const fragileVersion = 1000
+
+// fragileSim crashes when the player picks 3 times, like a counter that
+// overflows.
+type fragileSim struct {
+	picks int
+}
+
+func (s *fragileSim) Step(input PlayerInput) {
+	if input.Pick {
+		s.picks++
+	}
+	if s.picks == 3 {
+		Check(fmt.Errorf("picked too many times"))
+	}
+}
+
+func (s *fragileSim) State() any {
+	return s
+}
+
+func init() {
+	RegisterSimulation(fragileVersion, func(seed Int) Simulation {
+		return &fragileSim{}
+	})
+}
+
+// pickyVersion is the version of pickySim, registered only for these tests.
+const pickyVersion = 1001
+
+// pickySim crashes like fragileSim, but also crashes if the player moves left
+// before picking.
+type pickySim struct {
+	fragileSim
+}
+
+func (s *pickySim) Step(input PlayerInput) {
+	if input.MoveLeft && s.picks == 0 {
+		Check(fmt.Errorf("moved before picking"))
+	}
+	s.fragileSim.Step(input)
+}
+
+func init() {
+	RegisterSimulation(pickyVersion, func(seed Int) Simulation {
+		return &pickySim{}
+	})
+}
+
+func testArea() Rectangle {
+	return Rectangle{UPt(-900, -900), UPt(1800, 1800)}
+}
+
+func TestRandomInputs(t *testing.T) {
+	area := testArea()
+	inputs := RandomInputs(rand.New(rand.NewSource(3)), 1000, area)
+	assert.Equal(t, 1000, len(inputs))
+	for _, input := range inputs {
+		assert.True(t, area.ContainsPt(input.Position))
+	}
+	assert.Equal(t, inputs, RandomInputs(rand.New(rand.NewSource(3)), 1000, area))
+}
+
+func TestReplay(t *testing.T) {
+	var p Playthrough
+	p.Version = fragileVersion
+	p.History = make([]PlayerInput, 10)
+	crashed, _ := Replay(p)
+	assert.False(t, crashed)
+
+	p.History[2].Pick = true
+	p.History[4].Pick = true
+	p.History[7].Pick = true
+	crashed, c := Replay(p)
+	assert.True(t, crashed)
+	assert.Equal(t, 7, c.Frame)
+	assert.Contains(t, c.Message, "picked too many times")
+	assert.Contains(t, panicLocation(c.Stack), "stress_test.go")
+	assert.Equal(t, &fragileSim{3}, c.State)
+}
+
+func TestRunAndMinimize(t *testing.T) {
+	cfg := Config{fragileVersion, 5, 10, 300, testArea()}
+	crashes := Run(cfg)
+	assert.NotEmpty(t, crashes)
+	// The same config gives the same crashes. The stacks have addresses
+	// which change, so only the playthroughs are compared.
+	again := Run(cfg)
+	assert.Equal(t, len(crashes), len(again))
+	for i := range crashes {
+		assert.Equal(t, crashes[i].Playthrough, again[i].Playthrough)
+		assert.Equal(t, crashes[i].Frame, again[i].Frame)
+	}
+
+	for _, c := range crashes {
+		m := Minimize(c)
+		assert.Equal(t, 3, len(m.Playthrough.History))
+		assert.Equal(t, 2, m.Frame)
+		for _, input := range m.Playthrough.History {
+			assert.True(t, input.Pick)
+		}
+		crashed, _ := Replay(m.Playthrough)
+		assert.True(t, crashed)
+	}
+}
+
+func TestRun_World(t *testing.T) {
+	cfg := Config{Version, 1, 5, 500, testArea()}
+	for _, c := range Run(cfg) {
+		t.Errorf("crash at frame %d: %s", c.Frame, c.Message)
+	}
+}
+
+func TestMinimize_SameCrash(t *testing.T) {
+	var p Playthrough
+	p.Version = pickyVersion
+	p.History = []PlayerInput{{Pick: true}, {MoveLeft: true}, {Pick: true},
+		{Pick: true}}
+	crashed, c := Replay(p)
+	assert.True(t, crashed)
+
+	// Removing the first pick makes it crash because of moving left, which
+	// is a different bug.
+	m := Minimize(c)
+	assert.Equal(t, c.Message, m.Message)
+	assert.Equal(t, 3, len(m.Playthrough.History))
+	for _, input := range m.Playthrough.History {
+		assert.True(t, input.Pick)
+	}
+}
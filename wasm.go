@@ -10,3 +10,7 @@ func getUsername() string {
 	// Retrieve parameter from JavaScript global scope.
 	return js.Global().Get("username").String()
 }
+
+func getPlatform() string {
+	return "wasm"
+}
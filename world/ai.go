@@ -39,12 +39,12 @@ func (a *Ai) Step(w *World, c *Character, input PlayerInput) {
 	case MoveToFood:
 		c.MoveToFood(w)
 	case MoveLeft:
-		c.MoveLeft()
+		c.MoveLeft(w)
 	case MoveRight:
-		c.MoveRight()
+		c.MoveRight(w)
 	case MoveUp:
-		c.MoveUp()
+		c.MoveUp(w)
 	case MoveDown:
-		c.MoveDown()
+		c.MoveDown(w)
 	}
 }
@@ -1,73 +1,92 @@
 package world
 
 import (
+	"encoding/json"
 	. "github.com/marisvali/vlok/gamelib"
 )
 
 type Character struct {
-	Pos        Pt
-	Size       Pt
-	MaxHealth  Int
-	Health     Int
-	Picked     bool
-	Speed      Int
-	Ai         Ai
-	MoveLimits Rectangle
+	Pos  Pt
+	Size Pt
+	// CollisionSize is the size of the box that collides with the room. It
+	// is smaller than Size, which is how big the character is drawn, so
+	// that the character fits through corridors one position wide.
+	CollisionSize Pt
+	MaxHealth     Int
+	Health        Int
+	Picked        bool
+	Speed         Int
+	Ai            Ai
+	// MoveLimits is where the center of the collision box can be. Rooms
+	// with angled walls use a Polygon.
+	MoveLimits Area
 }
 
 func NewCharacter() (c Character) {
 	c.MaxHealth = I(3)
 	c.Health = c.MaxHealth
 	c.Speed = U(5)
-	c.MoveLimits = Rectangle{UPt(120, 90), UPt(790, 790)}
+	c.CollisionSize = UPt(40, 40)
+	c.MoveLimits = &Rectangle{UPt(120, 90), UPt(790, 790)}
 	return
 }
 
+// UnmarshalJSON reads a Character saved with json.Marshal. It is needed
+// because MoveLimits is an interface, see UnmarshalArea.
+func (c *Character) UnmarshalJSON(data []byte) error {
+	type character Character
+	var fields struct {
+		*character
+		MoveLimits json.RawMessage
+	}
+	fields.character = (*character)(c)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var err error
+	c.MoveLimits, err = UnmarshalArea(fields.MoveLimits)
+	return err
+}
+
 func (c *Character) MoveToFood(w *World) {
 	if c.Pos.DistTo(w.Food.Pos).Gt(U(3)) {
 		dir := c.Pos.To(w.Food.Pos)
 		dir.SetLen(c.Speed)
-		c.Pos.Add(dir)
+		c.ChangePos(w, c.Pos.Plus(dir))
 	}
 }
 
-func (c *Character) ChangePos(newPos Pt) {
-	// Check if the new position is valid.
-	if c.MoveLimits.ContainsPt(newPos) {
-		c.Pos = newPos
-	}
+// ChangePos moves the character towards newPos. If it hits a blocked part
+// of the room or the edge of MoveLimits on the way, it stops there and
+// slides along it.
+func (c *Character) ChangePos(w *World, newPos Pt) {
+	c.Pos = SweepPtInArea(c.MoveLimits, c.Pos, c.Pos.To(newPos), w.Obstacles())
 }
 
-func (c *Character) Move(dir Pt) {
+func (c *Character) Move(w *World, dir Pt) {
 	dir.SetLen(c.Speed)
-	c.ChangePos(c.Pos.Plus(dir))
-}
-
-func (c *Character) MoveLeft() {
-	c.Move(UPt(-1, 0))
+	c.ChangePos(w, c.Pos.Plus(dir))
 }
 
-func (c *Character) MoveRight() {
-	c.Move(UPt(1, 0))
+func (c *Character) MoveLeft(w *World) {
+	c.Move(w, UPt(-1, 0))
 }
 
-func (c *Character) MoveUp() {
-	c.Move(UPt(0, -1))
+func (c *Character) MoveRight(w *World) {
+	c.Move(w, UPt(1, 0))
 }
 
-func (c *Character) MoveDown() {
-	c.Move(UPt(0, 1))
+func (c *Character) MoveUp(w *World) {
+	c.Move(w, UPt(0, -1))
 }
 
-func (c *Character) ClosestValidPos(pos Pt) Pt {
-	x := Max(c.MoveLimits.Min().X, Min(pos.X, c.MoveLimits.Max().X))
-	y := Max(c.MoveLimits.Min().Y, Min(pos.Y, c.MoveLimits.Max().Y))
-	return Pt{x, y}
+func (c *Character) MoveDown(w *World) {
+	c.Move(w, UPt(0, 1))
 }
 
 func (c *Character) Step(w *World, input PlayerInput) {
 	if c.Picked {
-		c.ChangePos(c.ClosestValidPos(input.Position))
+		c.ChangePos(w, input.Position)
 	} else {
 		c.Ai.Step(w, c, input)
 	}
@@ -0,0 +1,40 @@
+package world
+
+import (
+	"encoding/json"
+	. "github.com/marisvali/vlok/gamelib"
+)
+
+// CrashReport describes a panic of the game, such as a failed Check or an Int
+// overflow. It's stored next to the recording of the playthrough that
+// crashed, so that we can replay the inputs and see what happened.
+type CrashReport struct {
+	// The value passed to panic.
+	Message string `json:"message"`
+	// The stack trace at the point of the panic.
+	Stack string `json:"stack"`
+	// Index of the last input of the playthrough when the game crashed. -1
+	// means it crashed before the first input.
+	Frame int64 `json:"frame"`
+	// The state of the world when the game crashed, as JSON, so that tools
+	// can load it back. It may be halfway through a Step. It's empty if the
+	// state couldn't be converted.
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// CrashReportEntry is the name of the entry that has the crash, in recording
+// archives.
+const CrashReportEntry = "crash.json"
+
+// SaveToArchive adds the crash to a recording archive.
+func (c *CrashReport) SaveToArchive(a *ArchiveWriter) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	Check(err)
+	a.Add(CrashReportEntry, data)
+}
+
+// LoadCrashReportFromArchive reads the crash from a recording archive.
+func LoadCrashReportFromArchive(a *ArchiveReader) (c CrashReport) {
+	Check(json.Unmarshal(a.Read(CrashReportEntry), &c))
+	return
+}
@@ -0,0 +1,50 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCrashReport_Archive(t *testing.T) {
+	c := CrashReport{"overflow", "goroutine 1 [running]:", 42,
+		json.RawMessage(`{"Seed":{"Val":5}}`)}
+	p := NewPlaythrough(I(5))
+	p.History = make([]PlayerInput, 43)
+
+	buf := new(bytes.Buffer)
+	w := NewArchiveWriter(buf)
+	p.SaveToArchive(w)
+	c.SaveToArchive(w)
+	w.Close()
+
+	r := NewArchiveReaderFromBytes(buf.Bytes())
+	loaded := LoadCrashReportFromArchive(r)
+	assert.JSONEq(t, string(c.State), string(loaded.State))
+	loaded.State = c.State
+	assert.Equal(t, c, loaded)
+	assert.Equal(t, p.History, LoadPlaythroughFromArchive(r).History)
+}
+
+func TestCrashReport_State(t *testing.T) {
+	// The state in a crash report can be loaded back into a World.
+	w := NewWorld(I(3))
+	w.Step(PlayerInput{MoveRight: true})
+	state, err := json.Marshal(&w)
+	assert.Nil(t, err)
+
+	var loaded World
+	assert.Nil(t, json.Unmarshal(state, &loaded))
+	assert.Equal(t, w, loaded)
+
+	// Move limits of any shape are loaded back.
+	w.Character.MoveLimits = NewPolygon(UPt(100, 100), UPt(800, 100),
+		UPt(100, 800))
+	state, err = json.Marshal(&w)
+	assert.Nil(t, err)
+	loaded = World{}
+	assert.Nil(t, json.Unmarshal(state, &loaded))
+	assert.Equal(t, w, loaded)
+}
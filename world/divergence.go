@@ -0,0 +1,78 @@
+package world
+
+import (
+	"encoding/binary"
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+)
+
+// StateHashesEntry is the name of the entry that has the hash of the state
+// after each frame, in recording archives. Replaying the recording with
+// other code and comparing hashes shows if the other code gives the same
+// game.
+const StateHashesEntry = "state-hashes"
+
+// SaveStateHashes adds the hashes to a recording archive.
+func SaveStateHashes(a *ArchiveWriter, hashes []uint64) {
+	data := make([]byte, 0, len(hashes)*8)
+	for _, h := range hashes {
+		data = binary.LittleEndian.AppendUint64(data, h)
+	}
+	a.Add(StateHashesEntry, data)
+}
+
+// LoadStateHashes reads the hashes from a recording archive.
+func LoadStateHashes(a *ArchiveReader) (hashes []uint64) {
+	data := a.Read(StateHashesEntry)
+	if len(data)%8 != 0 {
+		Check(fmt.Errorf("state hashes have %d bytes, expected a multiple "+
+			"of 8", len(data)))
+	}
+	for i := 0; i < len(data); i += 8 {
+		hashes = append(hashes, binary.LittleEndian.Uint64(data[i:]))
+	}
+	return
+}
+
+// Divergence is the first difference between two replays of the same
+// inputs.
+type Divergence struct {
+	// Index of the input after which the states differ. -1 means they differ
+	// before any input, when the level is created.
+	Frame int
+	// Differences between the states. Empty when only hashes were compared.
+	Diffs []FieldDiff
+}
+
+// FindDivergence steps a and b with the same inputs and returns the first
+// frame after which their states differ.
+func FindDivergence(a, b Simulation, inputs []PlayerInput) (bool, Divergence) {
+	if diffs := Diff(a.State(), b.State()); len(diffs) > 0 {
+		return true, Divergence{-1, diffs}
+	}
+	for i, input := range inputs {
+		a.Step(input)
+		b.Step(input)
+		if diffs := Diff(a.State(), b.State()); len(diffs) > 0 {
+			return true, Divergence{i, diffs}
+		}
+	}
+	return false, Divergence{}
+}
+
+// FindHashDivergence steps s with inputs and returns the first frame after
+// which the hash of the state is different from the one in hashes. If there
+// are fewer hashes than inputs, only the frames that have hashes are
+// checked. s is left in the state after the divergence, for inspection.
+func FindHashDivergence(s Simulation, inputs []PlayerInput, hashes []uint64) (bool, Divergence) {
+	for i, input := range inputs {
+		if i >= len(hashes) {
+			break
+		}
+		s.Step(input)
+		if HashState(s.State()) != hashes[i] {
+			return true, Divergence{Frame: i}
+		}
+	}
+	return false, Divergence{}
+}
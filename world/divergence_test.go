@@ -0,0 +1,77 @@
+package world
+
+import (
+	"bytes"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// brokenWorld gives the same game as World, except that the character moves
+// one unit more to the right after frame 3.
+type brokenWorld struct {
+	World
+}
+
+func (w *brokenWorld) Step(input PlayerInput) {
+	w.World.Step(input)
+	if w.TimeStep.Gt(I(3)) {
+		w.Character.Pos.X.Inc()
+	}
+}
+
+func (w *brokenWorld) State() any {
+	return &w.World
+}
+
+func testInputs() []PlayerInput {
+	return make([]PlayerInput, 10)
+}
+
+func TestFindDivergence(t *testing.T) {
+	a, err := NewSimulation(Version, I(5))
+	assert.Nil(t, err)
+	b, err := NewSimulation(Version, I(5))
+	assert.Nil(t, err)
+	found, _ := FindDivergence(a, b, testInputs())
+	assert.False(t, found)
+
+	a, _ = NewSimulation(Version, I(5))
+	b = &brokenWorld{NewWorld(I(5))}
+	found, d := FindDivergence(a, b, testInputs())
+	assert.True(t, found)
+	assert.Equal(t, 3, d.Frame)
+	assert.Equal(t, 1, len(d.Diffs))
+	assert.Equal(t, "Character.Pos.X", d.Diffs[0].Path)
+
+	// Different levels differ from the start.
+	a, _ = NewSimulation(Version, I(5))
+	b, _ = NewSimulation(Version, I(6))
+	found, d = FindDivergence(a, b, testInputs())
+	assert.True(t, found)
+	assert.Equal(t, -1, d.Frame)
+}
+
+func TestFindHashDivergence(t *testing.T) {
+	// Record the hashes, like the game does.
+	w := NewWorld(I(5))
+	var hashes []uint64
+	for _, input := range testInputs() {
+		w.Step(input)
+		hashes = append(hashes, HashState(w.State()))
+	}
+
+	buf := new(bytes.Buffer)
+	a := NewArchiveWriter(buf)
+	SaveStateHashes(a, hashes)
+	a.Close()
+	assert.Equal(t, hashes, LoadStateHashes(NewArchiveReaderFromBytes(buf.Bytes())))
+
+	s, _ := NewSimulation(Version, I(5))
+	found, _ := FindHashDivergence(s, testInputs(), hashes)
+	assert.False(t, found)
+
+	found, d := FindHashDivergence(&brokenWorld{NewWorld(I(5))}, testInputs(), hashes)
+	assert.True(t, found)
+	assert.Equal(t, 3, d.Frame)
+}
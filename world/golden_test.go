@@ -0,0 +1,63 @@
+package world_test
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/world"
+	_ "github.com/marisvali/vlok/world/v1"
+	_ "github.com/marisvali/vlok/world/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// goldenHashes has the hash of the final state of the golden replay, for
+// every version. They must never change for a version that was released, as
+// that means old playthroughs replay differently. A new version needs a new
+// hash, which is whatever the test reports.
+var goldenHashes = map[int64]uint64{
+	1: 0xd1174b8612bfdf0f,
+	2: 0xbb5da68bdd731047,
+	3: 0x004f9229b3fd5b03,
+}
+
+// goldenInputs returns the same inputs on every run. It uses its own
+// generator instead of Rand, so that changes to Rand don't change the
+// inputs.
+func goldenInputs(n int) (inputs []world.PlayerInput) {
+	x := uint64(12345)
+	next := func(max int) int {
+		x = x*6364136223846793005 + 1442695040888963407
+		return int(x>>33) % max
+	}
+	for i := 0; i < n; i++ {
+		var input world.PlayerInput
+		input.Position = UPt(next(900), next(900))
+		input.Pick = next(10) == 0
+		input.Release = next(10) == 0
+		input.MoveLeft = next(4) == 0
+		input.MoveRight = next(4) == 0
+		input.MoveUp = next(4) == 0
+		input.MoveDown = next(4) == 0
+		input.MoveToFood = next(10) == 0
+		inputs = append(inputs, input)
+	}
+	return
+}
+
+func TestSimulation_GoldenHashes(t *testing.T) {
+	inputs := goldenInputs(2000)
+	for _, version := range world.SimulationVersions() {
+		// Replay a few levels, to cover different rooms.
+		var states []any
+		for _, seed := range []int{0, 1, 42} {
+			s, err := world.NewSimulation(version, I(seed))
+			assert.Nil(t, err)
+			for _, input := range inputs {
+				s.Step(input)
+			}
+			states = append(states, s.State())
+		}
+		assert.Contains(t, goldenHashes, version)
+		assert.Equal(t, goldenHashes[version], HashState(states),
+			"version %d", version)
+	}
+}
@@ -0,0 +1,155 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	"io"
+)
+
+/*
+The inputs of a playthrough are encoded compactly, because most frames are
+the same as the previous one: the mouse doesn't move and no key is pressed.
+
+The inputs are split into runs of identical consecutive frames. Each run is
+encoded as:
+- flags: 1 byte, one bit for each bool of PlayerInput, plus inputMoved if the
+position is different from the one of the previous run
+- if inputMoved is set, the change of X and of Y since the previous run, as
+signed varints
+- the number of frames in the run minus 1, as an unsigned varint
+
+The position before the first run is (0, 0).
+*/
+
+const (
+	inputPick byte = 1 << iota
+	inputRelease
+	inputMoveLeft
+	inputMoveRight
+	inputMoveUp
+	inputMoveDown
+	inputMoveToFood
+	inputMoved
+)
+
+func inputFlags(input PlayerInput) (flags byte) {
+	bits := []bool{input.Pick, input.Release, input.MoveLeft, input.MoveRight,
+		input.MoveUp, input.MoveDown, input.MoveToFood}
+	for i, b := range bits {
+		if b {
+			flags |= 1 << i
+		}
+	}
+	return
+}
+
+func setInputFlags(input *PlayerInput, flags byte) {
+	input.Pick = flags&inputPick != 0
+	input.Release = flags&inputRelease != 0
+	input.MoveLeft = flags&inputMoveLeft != 0
+	input.MoveRight = flags&inputMoveRight != 0
+	input.MoveUp = flags&inputMoveUp != 0
+	input.MoveDown = flags&inputMoveDown != 0
+	input.MoveToFood = flags&inputMoveToFood != 0
+}
+
+// EncodeInputs encodes inputs compactly, as described above.
+func EncodeInputs(inputs []PlayerInput) (data []byte) {
+	var prev PlayerInput
+	for i := 0; i < len(inputs); {
+		// Find the end of the run.
+		n := 1
+		for i+n < len(inputs) && inputs[i+n] == inputs[i] {
+			n++
+		}
+
+		input := inputs[i]
+		flags := inputFlags(input)
+		if input.Position != prev.Position {
+			flags |= inputMoved
+		}
+		data = append(data, flags)
+		if flags&inputMoved != 0 {
+			delta := prev.Position.To(input.Position)
+			data = binary.AppendVarint(data, delta.X.ToInt64())
+			data = binary.AppendVarint(data, delta.Y.ToInt64())
+		}
+		data = binary.AppendUvarint(data, uint64(n-1))
+
+		prev = input
+		i += n
+	}
+	return
+}
+
+// InputDecoder decodes inputs encoded by EncodeInputs one frame at a time, so
+// that a playthrough can be replayed while it is still being decoded.
+type InputDecoder struct {
+	r         io.ByteReader
+	current   PlayerInput
+	remaining uint64
+	err       error
+}
+
+func NewInputDecoder(r io.ByteReader) *InputDecoder {
+	return &InputDecoder{r: r}
+}
+
+// Next returns the input of the next frame. It returns false at the end of
+// the inputs or if the data is damaged, in which case Err returns the error.
+func (d *InputDecoder) Next() (PlayerInput, bool) {
+	if d.remaining > 0 {
+		d.remaining--
+		return d.current, true
+	}
+	if d.err != nil {
+		return PlayerInput{}, false
+	}
+
+	flags, err := d.r.ReadByte()
+	if err == io.EOF {
+		return PlayerInput{}, false
+	}
+	if err != nil {
+		d.err = err
+		return PlayerInput{}, false
+	}
+
+	setInputFlags(&d.current, flags)
+	if flags&inputMoved != 0 {
+		dx, errX := binary.ReadVarint(d.r)
+		dy, errY := binary.ReadVarint(d.r)
+		if err = errors.Join(errX, errY); err != nil {
+			d.err = fmt.Errorf("failed to read position: %w", err)
+			return PlayerInput{}, false
+		}
+		d.current.Position.Add(Pt{I64(dx), I64(dy)})
+	}
+	d.remaining, err = binary.ReadUvarint(d.r)
+	if err != nil {
+		d.err = fmt.Errorf("failed to read run length: %w", err)
+		return PlayerInput{}, false
+	}
+	return d.current, true
+}
+
+// Err returns the error that stopped Next, if any.
+func (d *InputDecoder) Err() error {
+	return d.err
+}
+
+// DecodeInputs decodes all the inputs encoded by EncodeInputs.
+func DecodeInputs(data []byte) (inputs []PlayerInput, err error) {
+	d := NewInputDecoder(bytes.NewReader(data))
+	for {
+		input, ok := d.Next()
+		if !ok {
+			break
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, d.Err()
+}
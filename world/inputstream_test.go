@@ -0,0 +1,132 @@
+package world
+
+import (
+	"bytes"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func randomInputs(n int) (inputs []PlayerInput) {
+	var input PlayerInput
+	for i := 0; i < n; i++ {
+		// Change something only now and then, like a real player.
+		if RInt(I(0), I(9)).IsZero() {
+			input.Position = Pt{RInt(I(-1000), I(90000)), RInt(I(0), I(90000))}
+			input.Pick = RInt(I(0), I(1)).IsZero()
+			input.Release = RInt(I(0), I(1)).IsZero()
+			input.MoveLeft = RInt(I(0), I(1)).IsZero()
+			input.MoveToFood = RInt(I(0), I(1)).IsZero()
+		}
+		inputs = append(inputs, input)
+	}
+	return
+}
+
+func TestEncodeInputs(t *testing.T) {
+	RSeed(I(3))
+	inputs := randomInputs(1000)
+	data := EncodeInputs(inputs)
+	decoded, err := DecodeInputs(data)
+	assert.Nil(t, err)
+	assert.Equal(t, inputs, decoded)
+
+	// Much smaller than the raw inputs.
+	raw := new(bytes.Buffer)
+	SerializeSlice(raw, inputs)
+	assert.Less(t, len(data)*10, raw.Len())
+
+	// Nothing to encode.
+	decoded, err = DecodeInputs(EncodeInputs(nil))
+	assert.Nil(t, err)
+	assert.Empty(t, decoded)
+
+	// A long idle stretch is a few bytes.
+	idle := make([]PlayerInput, 10000)
+	assert.Equal(t, 3, len(EncodeInputs(idle)))
+}
+
+func TestInputDecoder(t *testing.T) {
+	inputs := []PlayerInput{
+		{Position: IPt(10, 10)},
+		{Position: IPt(10, 10)},
+		{Position: IPt(5, 20), Pick: true},
+		{Position: IPt(5, 20)},
+	}
+	data := EncodeInputs(inputs)
+
+	// Inputs come out one at a time.
+	d := NewInputDecoder(bytes.NewReader(data))
+	for _, expected := range inputs {
+		input, ok := d.Next()
+		assert.True(t, ok)
+		assert.Equal(t, expected, input)
+	}
+	_, ok := d.Next()
+	assert.False(t, ok)
+	assert.Nil(t, d.Err())
+
+	// Damaged data gives an error.
+	_, err := DecodeInputs(data[:len(data)-1])
+	assert.NotNil(t, err)
+}
+
+func TestDeserializePlaythrough_Version1(t *testing.T) {
+	// Write a playthrough like version 1 did.
+	inputs := []PlayerInput{{Position: IPt(1, 2)}, {MoveUp: true}, {MoveUp: true}}
+	d := NewDocument(1)
+	buf := new(bytes.Buffer)
+	Serialize(buf, I(42))
+	d.Add("seed", buf.Bytes())
+	buf = new(bytes.Buffer)
+	SerializeSlice(buf, inputs)
+	d.Add("history", buf.Bytes())
+
+	p := DeserializePlaythrough(d.Bytes())
+	assert.Equal(t, int64(1), p.Version)
+	assert.Equal(t, I(42), p.Seed)
+	assert.Equal(t, inputs, p.History)
+
+	// It keeps the version when saved again, so that it still replays under
+	// the rules of version 1.
+	assert.Equal(t, int64(1), DeserializePlaythrough(p.Serialize()).Version)
+}
+
+// countingReader counts how many bytes were read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestReadPlaythroughWithDecoder(t *testing.T) {
+	p := NewPlaythrough(I(7))
+	p.History = randomInputs(20000)
+	data := p.Serialize()
+
+	// Only the start of the history is read to decode the first frame.
+	r := &countingReader{bytes.NewReader(data), 0}
+	loaded, inputs := ReadPlaythroughWithDecoder(r)
+	assert.Equal(t, p.Version, loaded.Version)
+	assert.Equal(t, p.Seed, loaded.Seed)
+	assert.Empty(t, loaded.History)
+	input, ok := inputs.Next()
+	assert.True(t, ok)
+	assert.Equal(t, p.History[0], input)
+	assert.Less(t, r.n, len(data)/2)
+	assert.Equal(t, p.History[1:], decodeAll(inputs))
+
+	// The history doesn't have to be the last section.
+	d := NewDocument(Version)
+	d.Add("history", EncodeInputs(p.History))
+	buf := new(bytes.Buffer)
+	Serialize(buf, p.Seed)
+	d.Add("seed", buf.Bytes())
+	assert.Equal(t, p, DeserializePlaythrough(d.Bytes()))
+}
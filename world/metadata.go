@@ -0,0 +1,66 @@
+package world
+
+import (
+	"encoding/json"
+	. "github.com/marisvali/vlok/gamelib"
+	"time"
+)
+
+// Outcome says how a recorded playthrough ended.
+type Outcome string
+
+const (
+	// The player restarted the level.
+	OutcomeRestarted Outcome = "restarted"
+	// The player asked for a new level.
+	OutcomeNewLevel Outcome = "new-level"
+	// The player closed the game.
+	OutcomeQuit Outcome = "quit"
+	// The game crashed. The recording also has a CrashReport entry.
+	OutcomeCrashed Outcome = "crashed"
+)
+
+// Metadata gives the context of a recording. It's stored as JSON in its own
+// entry of the recording archive, so it can be read without decoding the
+// playthrough.
+type Metadata struct {
+	User    string `json:"user"`
+	Version int64  `json:"version"`
+	// Unique id of the playthrough. Crash bundles are saved and uploaded
+	// under this id.
+	Id string `json:"id"`
+	// Either "native" or "wasm".
+	Platform  string    `json:"platform"`
+	Seed      int64     `json:"seed"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// Number of frames in the playthrough.
+	Frames  int64   `json:"frames"`
+	Outcome Outcome `json:"outcome"`
+}
+
+// MetadataEntry is the name of the entry that has the metadata, in recording
+// archives.
+const MetadataEntry = "metadata.json"
+
+// SaveToArchive adds the metadata to a recording archive.
+func (m *Metadata) SaveToArchive(a *ArchiveWriter) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	Check(err)
+	a.Add(MetadataEntry, data)
+}
+
+// LoadMetadataFromArchive reads the metadata from a recording archive. Only
+// the metadata entry is read.
+func LoadMetadataFromArchive(a *ArchiveReader) (m Metadata) {
+	Check(json.Unmarshal(a.Read(MetadataEntry), &m))
+	return
+}
+
+// ReadRecordingMetadata reads the metadata of a recording file, without
+// reading the playthrough.
+func ReadRecordingMetadata(filename string) Metadata {
+	a := OpenArchive(filename)
+	defer a.Close()
+	return LoadMetadataFromArchive(a)
+}
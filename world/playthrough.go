@@ -0,0 +1,214 @@
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	"io"
+)
+
+// Playthrough has everything needed to replay a game: the rules, the level
+// and the inputs of the player at each frame.
+type Playthrough struct {
+	// Version of the game that recorded the playthrough. Replay uses the
+	// rules of this version.
+	Version int64
+	Seed    Int
+	History []PlayerInput
+}
+
+// playthroughMigrations converts playthroughs recorded by older versions of
+// the game, so that they can still be replayed. playthroughMigrations[v]
+// converts from version v to version v+1. When PlayerInput or anything else
+// that is serialized changes, increase Version and add a migration here.
+var playthroughMigrations = map[int64]Migration{
+	1: migrateHistoryToEncodedInputs,
+	// Version 3 changed the rules, not how playthroughs are stored.
+	2: keepSections,
+}
+
+func keepSections(sections []Section) ([]Section, error) {
+	return sections, nil
+}
+
+// playerInputV1 is PlayerInput as it was in version 1.
+type playerInputV1 struct {
+	Position   Pt
+	Pick       bool
+	Release    bool
+	MoveLeft   bool
+	MoveRight  bool
+	MoveUp     bool
+	MoveDown   bool
+	MoveToFood bool
+}
+
+// migrateHistoryToEncodedInputs converts from version 1, which stored the
+// history as a raw slice of PlayerInput, to version 2, which uses
+// EncodeInputs.
+func migrateHistoryToEncodedInputs(sections []Section) ([]Section, error) {
+	for i := range sections {
+		if sections[i].Name != "history" {
+			continue
+		}
+		var old []playerInputV1
+		DeserializeSlice(bytes.NewBuffer(sections[i].Data), &old)
+		inputs := make([]PlayerInput, len(old))
+		for j := range old {
+			inputs[j] = PlayerInput(old[j])
+		}
+		sections[i].Data = EncodeInputs(inputs)
+	}
+	return sections, nil
+}
+
+// NewPlaythrough starts a playthrough of the level generated by seed, under
+// the rules of the current Version.
+func NewPlaythrough(seed Int) (p Playthrough) {
+	p.Version = Version
+	p.Seed = seed
+	return
+}
+
+// Serialize crashes if p has no version, as the playthrough couldn't be
+// replayed under the right rules.
+func (p *Playthrough) Serialize() []byte {
+	if p.Version <= 0 {
+		Check(fmt.Errorf("playthrough has invalid version %d", p.Version))
+	}
+	d := NewDocument(Version)
+
+	buf := new(bytes.Buffer)
+	Serialize(buf, p.Version)
+	d.Add("version", buf.Bytes())
+
+	buf = new(bytes.Buffer)
+	Serialize(buf, p.Seed)
+	d.Add("seed", buf.Bytes())
+
+	d.Add("history", EncodeInputs(p.History))
+
+	return d.Bytes()
+}
+
+// DeserializePlaythrough reads a playthrough recorded by this or an older
+// version of the game.
+func DeserializePlaythrough(data []byte) (p Playthrough) {
+	p, inputs := DeserializePlaythroughWithDecoder(data)
+	p.History = decodeAll(inputs)
+	return
+}
+
+// DeserializePlaythroughWithDecoder is like DeserializePlaythrough, but leaves
+// p.History empty and returns a decoder for it instead, see
+// ReadPlaythroughWithDecoder.
+func DeserializePlaythroughWithDecoder(data []byte) (p Playthrough, inputs *InputDecoder) {
+	return ReadPlaythroughWithDecoder(bytes.NewReader(data))
+}
+
+// ReadPlaythroughWithDecoder reads a playthrough from r, but leaves p.History
+// empty and returns a decoder for it instead. The sections of the document
+// are read one by one and the inputs are decoded from r one frame at a time,
+// so a replay never has all of them in memory. r must stay open until the
+// decoder is done.
+// Playthroughs recorded by older versions are read in full first, as
+// migrations work on whole sections.
+func ReadPlaythroughWithDecoder(r io.Reader) (p Playthrough, inputs *InputDecoder) {
+	d, err := NewDocumentReader(r)
+	Check(err)
+	// The version of the document changes when migrating it, but the
+	// version of the rules stays the same. Documents without a version
+	// section were recorded with the rules of their own version.
+	p.Version = d.Version
+	if d.Version != Version {
+		doc, err := d.ReadAll()
+		Check(err)
+		doc, err = MigrateDocument(doc, Version, playthroughMigrations)
+		Check(err)
+		if doc.Has("version") {
+			Deserialize(bytes.NewReader(doc.Get("version")), &p.Version)
+		}
+		Deserialize(bytes.NewReader(doc.Get("seed")), &p.Seed)
+		inputs = NewInputDecoder(bytes.NewReader(doc.Get("history")))
+		return
+	}
+
+	// Serialize writes the history last, so it can be decoded straight from
+	// r. If it comes before other sections, keep it in memory until they are
+	// read.
+	var history []byte
+	hasSeed := false
+	for {
+		name, section, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		Check(err)
+		switch name {
+		case "version":
+			Deserialize(section, &p.Version)
+		case "seed":
+			Deserialize(section, &p.Seed)
+			hasSeed = true
+		case "history":
+			if hasSeed {
+				inputs = NewInputDecoder(bufio.NewReader(section))
+				return
+			}
+			history, err = io.ReadAll(section)
+			Check(err)
+		}
+	}
+	if !hasSeed {
+		Check(fmt.Errorf("document has no section called seed"))
+	}
+	if history == nil {
+		Check(fmt.Errorf("document has no section called history"))
+	}
+	inputs = NewInputDecoder(bytes.NewReader(history))
+	return
+}
+
+// decodeAll returns all the inputs that are left in inputs.
+func decodeAll(inputs *InputDecoder) (history []PlayerInput) {
+	for {
+		input, ok := inputs.Next()
+		if !ok {
+			break
+		}
+		history = append(history, input)
+	}
+	Check(inputs.Err())
+	return
+}
+
+// Replay runs the playthrough under the rules it was recorded with and
+// returns the simulation in its final state.
+func (p *Playthrough) Replay() Simulation {
+	s, err := NewSimulation(p.Version, p.Seed)
+	Check(err)
+	for _, input := range p.History {
+		s.Step(input)
+	}
+	return s
+}
+
+// PlaythroughEntry is the name of the entry that has the playthrough, in
+// recording archives.
+const PlaythroughEntry = "playthrough"
+
+// SaveToArchive adds the playthrough to a recording archive.
+func (p *Playthrough) SaveToArchive(a *ArchiveWriter) {
+	a.Add(PlaythroughEntry, p.Serialize())
+}
+
+// LoadPlaythroughFromArchive reads the playthrough from a recording archive.
+// The entry is read as a stream, see ReadPlaythroughWithDecoder.
+func LoadPlaythroughFromArchive(a *ArchiveReader) (p Playthrough) {
+	rc := a.Open(PlaythroughEntry)
+	defer func(rc io.ReadCloser) { Check(rc.Close()) }(rc)
+	p, inputs := ReadPlaythroughWithDecoder(rc)
+	p.History = decodeAll(inputs)
+	return
+}
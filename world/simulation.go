@@ -0,0 +1,84 @@
+package world
+
+import (
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	"slices"
+)
+
+/*
+Simulation runs the rules of one version of the game.
+
+Recordings only have the inputs of the player, so replaying them under
+different rules gives a different game. To replay a recording exactly, we
+need the rules of the version it was recorded with. Each version of the rules
+registers a Simulation with RegisterSimulation and NewSimulation picks the
+one for a version.
+
+The rules of the current Version are World itself. When the rules change:
+- copy the current World and everything it uses into a package for the old
+version, such as world/v1, and make it register itself for that version in
+its init function
+- increase Version and change World
+
+Old simulations receive the current PlayerInput. When PlayerInput changes,
+the playthrough migrations convert the old inputs and the old simulations
+convert them back to what they expect. Programs that replay old recordings
+must import the packages of the old simulations, so that they get registered:
+
+	import _ "github.com/marisvali/vlok/world/v1"
+*/
+type Simulation interface {
+	// Step advances the simulation by one frame.
+	Step(input PlayerInput)
+	// State returns the current state of the simulation, for inspection. Its
+	// type depends on the version.
+	State() any
+}
+
+// SimulationFactory creates the level generated by seed.
+type SimulationFactory func(seed Int) Simulation
+
+var simulations = map[int64]SimulationFactory{}
+
+func init() {
+	RegisterSimulation(Version, func(seed Int) Simulation {
+		w := NewWorld(seed)
+		return &w
+	})
+}
+
+// RegisterSimulation makes NewSimulation use f for version. It crashes if
+// version already has a simulation.
+func RegisterSimulation(version int64, f SimulationFactory) {
+	if _, ok := simulations[version]; ok {
+		Check(fmt.Errorf("simulation for version %d is already registered", version))
+	}
+	simulations[version] = f
+}
+
+// NewSimulation creates the level generated by seed, under the rules of
+// version.
+func NewSimulation(version int64, seed Int) (Simulation, error) {
+	f, ok := simulations[version]
+	if !ok {
+		return nil, fmt.Errorf("no simulation for version %d, supported "+
+			"versions: %v", version, SimulationVersions())
+	}
+	return f(seed), nil
+}
+
+// SimulationVersions returns the versions that have a simulation, in
+// increasing order.
+func SimulationVersions() (versions []int64) {
+	for v := range simulations {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	return
+}
+
+// State implements Simulation.
+func (w *World) State() any {
+	return w
+}
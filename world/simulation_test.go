@@ -0,0 +1,45 @@
+package world
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewSimulation(t *testing.T) {
+	assert.Contains(t, SimulationVersions(), int64(Version))
+
+	_, err := NewSimulation(Version+1, I(3))
+	assert.NotNil(t, err)
+
+	s, err := NewSimulation(Version, I(3))
+	assert.Nil(t, err)
+	w := NewWorld(I(3))
+	assert.Equal(t, &w, s.State())
+
+	assert.Panics(t, func() {
+		RegisterSimulation(Version, func(seed Int) Simulation { return nil })
+	})
+}
+
+func TestPlaythrough_Replay(t *testing.T) {
+	p := NewPlaythrough(I(11))
+	p.History = []PlayerInput{
+		{MoveRight: true},
+		{},
+		{},
+		{MoveDown: true},
+		{},
+	}
+
+	loaded := DeserializePlaythrough(p.Serialize())
+	assert.Equal(t, int64(Version), loaded.Version)
+	assert.Equal(t, p.Seed, loaded.Seed)
+	assert.Equal(t, p.History, loaded.History)
+
+	w := NewWorld(p.Seed)
+	for _, input := range p.History {
+		w.Step(input)
+	}
+	assert.Equal(t, &w, loaded.Replay().State())
+}
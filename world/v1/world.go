@@ -0,0 +1,74 @@
+/*
+Package v1 has the rules of version 1 of the game, so that playthroughs
+recorded with that version can still be replayed exactly. The code is frozen:
+don't change it, even to fix bugs, as that would change how the old
+playthroughs play out. Version 1 levels don't depend on the seed.
+*/
+package v1
+
+import (
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/world"
+	"math"
+)
+
+const Version = 1
+
+func init() {
+	world.RegisterSimulation(Version, func(seed Int) world.Simulation {
+		w := NewWorld()
+		return &w
+	})
+}
+
+type Food struct {
+	Pos  Pt
+	Size Pt
+}
+
+type World struct {
+	Size      Pt
+	Character Character
+	Food      Food
+	TimeStep  Int
+}
+
+func NewWorld() (w World) {
+	w.Character = NewCharacter()
+
+	w.Size = UPt(900, 900)
+	sz := 200
+	w.Character.Size = UPt(sz, sz)
+	w.Character.Pos = UPt(100, 200)
+	w.Food.Size = UPt(200, 200)
+	w.Food.Pos = UPt(450, 450)
+	return
+}
+
+func (w *World) Step(input world.PlayerInput) {
+	if input.Pick {
+		if input.Position.DistTo(w.Character.Pos).Lt(U(150)) {
+			w.Character.Pick()
+		}
+	}
+
+	if input.Release {
+		if w.Character.IsPicked() {
+			w.Character.Release()
+		}
+	}
+
+	w.Character.Step(w, input)
+
+	w.TimeStep.Inc()
+	if w.TimeStep.Eq(I(math.MaxInt64)) {
+		// Damn.
+		Check(fmt.Errorf("got to an unusually large time step: %d", w.TimeStep.ToInt64()))
+	}
+}
+
+// State implements world.Simulation.
+func (w *World) State() any {
+	return w
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/world"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWorld_Registered(t *testing.T) {
+	inputs := []world.PlayerInput{
+		{MoveRight: true},
+		{},
+		{MoveDown: true},
+		{Position: UPt(300, 300), Pick: true},
+		{Position: UPt(320, 310)},
+		{Release: true},
+	}
+
+	expected := NewWorld()
+	for _, input := range inputs {
+		expected.Step(input)
+	}
+
+	var p world.Playthrough
+	p.Version = Version
+	p.Seed = I(17)
+	p.History = inputs
+	p = world.DeserializePlaythrough(p.Serialize())
+	assert.Equal(t, &expected, p.Replay().State())
+
+	// The seed doesn't change the level.
+	a, err := world.NewSimulation(Version, I(1))
+	assert.Nil(t, err)
+	b, err := world.NewSimulation(Version, I(2))
+	assert.Nil(t, err)
+	assert.Equal(t, a.State(), b.State())
+}
@@ -0,0 +1,144 @@
+package v2
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/world"
+)
+
+type Character struct {
+	Pos        Pt
+	Size       Pt
+	MaxHealth  Int
+	Health     Int
+	Picked     bool
+	Speed      Int
+	Ai         Ai
+	MoveLimits Rectangle
+}
+
+func NewCharacter() (c Character) {
+	c.MaxHealth = I(3)
+	c.Health = c.MaxHealth
+	c.Speed = U(5)
+	c.MoveLimits = Rectangle{UPt(120, 90), UPt(790, 790)}
+	return
+}
+
+func (c *Character) MoveToFood(w *World) {
+	if c.Pos.DistTo(w.Food.Pos).Gt(U(3)) {
+		dir := c.Pos.To(w.Food.Pos)
+		dir.SetLen(c.Speed)
+		c.Pos.Add(dir)
+	}
+}
+
+// ChangePos moves the character towards newPos. If newPos is outside
+// MoveLimits, the character stops at the edge and slides along it.
+func (c *Character) ChangePos(newPos Pt) {
+	c.Pos = slideInArea(&c.MoveLimits, c.Pos, c.Pos.To(newPos))
+}
+
+func (c *Character) Move(dir Pt) {
+	dir.SetLen(c.Speed)
+	c.ChangePos(c.Pos.Plus(dir))
+}
+
+func (c *Character) MoveLeft() {
+	c.Move(UPt(-1, 0))
+}
+
+func (c *Character) MoveRight() {
+	c.Move(UPt(1, 0))
+}
+
+func (c *Character) MoveUp() {
+	c.Move(UPt(0, -1))
+}
+
+func (c *Character) MoveDown() {
+	c.Move(UPt(0, 1))
+}
+
+func (c *Character) Step(w *World, input world.PlayerInput) {
+	if c.Picked {
+		c.ChangePos(input.Position)
+	} else {
+		c.Ai.Step(w, c, input)
+	}
+}
+
+func (c *Character) Pick() {
+	c.Picked = true
+}
+
+func (c *Character) Release() {
+	c.Picked = false
+}
+
+func (c *Character) IsPicked() bool {
+	return c.Picked
+}
+
+// slideInArea moves pos by delta and returns the new position, without
+// leaving area. If the move would leave the area, pos stops at its edge and
+// the rest of the move continues along the edge. If pos starts outside the
+// area, it goes to the point of the area closest to pos + delta.
+// It is a copy of the rules that version 2 shipped with, so that changes to
+// collisions in gamelib don't change how old playthroughs replay.
+func slideInArea(area Area, pos, delta Pt) Pt {
+	target := pos.Plus(delta)
+	if !area.ContainsPt(pos) {
+		return area.ClosestPt(target)
+	}
+
+	stop := lastInside(area, pos, target)
+	if stop == target {
+		return target
+	}
+	// Go towards the point of the area closest to the target. For convex
+	// areas, this is the same as sliding along the edge. For other areas, the
+	// closest point may be on the other side of a wall, so lastInside stops
+	// us at the wall.
+	return lastInside(area, stop, area.ClosestPt(target))
+}
+
+// lastInside goes from start to end and returns the last point inside area
+// before the segment leaves it. start must be inside area.
+// The segment is checked every Unit, so thin parts of the area may be
+// skipped.
+func lastInside(area Area, start, end Pt) Pt {
+	dir := start.To(end)
+	n := Max(dir.X.Abs(), dir.Y.Abs())
+	if n.IsZero() {
+		return end
+	}
+	at := func(t Int) Pt {
+		return start.Plus(dir.Times(t).DivBy(n))
+	}
+
+	// Find the first step that ends outside.
+	lo := ZERO
+	hi := n
+	for t := I(Unit); ; t.Add(I(Unit)) {
+		t = Min(t, n)
+		if !area.ContainsPt(at(t)) {
+			hi = t
+			break
+		}
+		if t.Eq(n) {
+			return end
+		}
+		lo = t
+	}
+
+	// at(lo) is inside, at(hi) is outside.
+	for lo.Plus(ONE).Lt(hi) {
+		mid := lo.Plus(hi).DivBy(TWO)
+		if area.ContainsPt(at(mid)) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return at(lo)
+}
@@ -0,0 +1,308 @@
+package v2
+
+import (
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	"math/rand"
+)
+
+// This file has copies of the room generator and the random numbers that
+// version 2 shipped with, so that changes to gamelib and gamelib/gen don't
+// change the levels of old playthroughs.
+
+// random gives the same numbers as gamelib.Rand did in version 2.
+type random struct {
+	r *rand.Rand
+}
+
+func newRandom(seed Int) *random {
+	return &random{rand.New(rand.NewSource(seed.ToInt64()))}
+}
+
+// Int returns a random number in the interval [min, max].
+func (r *random) Int(min Int, max Int) Int {
+	if max.Lt(min) {
+		panic(fmt.Errorf("min larger than max: %d %d", min, max))
+	}
+	dif := max.Minus(min).Plus(I(1))
+	randomValue := I64(r.r.Int63())
+	return randomValue.Mod(dif).Plus(min)
+}
+
+// randomFreePos returns a random position that is false in m. All such
+// positions have the same chance of being chosen. It returns an error if all
+// positions are true.
+func randomFreePos(m MatBool, r *random) (Pt, error) {
+	size := m.Size()
+	nFree := size.X.Times(size.Y).Minus(m.Count())
+	if nFree.IsZero() {
+		return Pt{}, fmt.Errorf("no unoccupied position in matrix of size "+
+			"(%d, %d)", size.X.ToInt(), size.Y.ToInt())
+	}
+
+	// Pick which free position we want and then go look for it, row by row.
+	k := r.Int(ZERO, nFree.Minus(ONE))
+	for y := ZERO; y.Lt(size.Y); y.Inc() {
+		for x := ZERO; x.Lt(size.X); x.Inc() {
+			pt := Pt{x, y}
+			if m.At(pt) {
+				continue
+			}
+			if k.IsZero() {
+				return pt, nil
+			}
+			k.Dec()
+		}
+	}
+	panic("unreachable: free positions were counted but not found")
+}
+
+// generateRoom generates a layout for a level, where true means the position
+// is blocked. It picks either a cave or a BSP layout and adds some furniture.
+func generateRoom(size Pt, r *random) (blocked MatBool) {
+	useCave := r.Int(ZERO, ONE).IsZero()
+	if useCave {
+		blocked = cave(size, r)
+	}
+	// Caves can end up with no free positions at all, especially in small
+	// layouts. BSP layouts always have at least one room.
+	if !useCave || blocked.Count().Eq(size.X.Times(size.Y)) {
+		blocked = bsp(size, r)
+	}
+
+	count := r.Int(I(2), I(5))
+	pieceSize := Pt{r.Int(ONE, TWO), r.Int(ONE, TWO)}
+	scatterFurniture(&blocked, r, count, pieceSize)
+	return
+}
+
+// cave generates a cave-like layout using a cellular automaton.
+func cave(size Pt, r *random) (blocked MatBool) {
+	fillPercent := I(40)
+	iterations := I(4)
+	birthLimit := I(5)
+	survivalLimit := I(4)
+
+	blocked = NewMatBool(size)
+	for y := ZERO; y.Lt(size.Y); y.Inc() {
+		for x := ZERO; x.Lt(size.X); x.Inc() {
+			if r.Int(ZERO, I(99)).Lt(fillPercent) {
+				blocked.Set(Pt{x, y})
+			}
+		}
+	}
+
+	dirs := Directions8()
+	for i := ZERO; i.Lt(iterations); i.Inc() {
+		next := NewMatBool(size)
+		for y := ZERO; y.Lt(size.Y); y.Inc() {
+			for x := ZERO; x.Lt(size.X); x.Inc() {
+				pt := Pt{x, y}
+				nBlocked := ZERO
+				for _, d := range dirs {
+					n := pt.Plus(d)
+					if !blocked.InBounds(n) || blocked.At(n) {
+						nBlocked.Inc()
+					}
+				}
+				if (blocked.At(pt) && nBlocked.Geq(survivalLimit)) ||
+					(!blocked.At(pt) && nBlocked.Geq(birthLimit)) {
+					next.Set(pt)
+				}
+			}
+		}
+		blocked = next
+	}
+
+	keepLargestFreeArea(&blocked)
+	return
+}
+
+// bsp generates a layout of rectangular rooms connected by corridors, by
+// recursively splitting the space in two (binary space partitioning).
+func bsp(size Pt, r *random) (blocked MatBool) {
+	blocked = NewMatBool(size)
+	blocked.SetAll()
+	bspSplit(&blocked, r, Rectangle{Pt{}, size.Minus(Pt{ONE, ONE})})
+	keepLargestFreeArea(&blocked)
+	return
+}
+
+const (
+	bspMinLeafSize = 6
+	bspMinRoomSize = 3
+)
+
+// bspSplit carves rooms inside r (which has inclusive corners) and connects
+// them. It returns a free position inside the carved rooms.
+func bspSplit(blocked *MatBool, rnd *random, r Rectangle) Pt {
+	minPt := r.Min()
+	maxPt := r.Max()
+	minLeafSize := I(bspMinLeafSize)
+	nPositions := r.Size().Plus(Pt{ONE, ONE})
+	canSplitX := nPositions.X.Geq(minLeafSize.Times(TWO))
+	canSplitY := nPositions.Y.Geq(minLeafSize.Times(TWO))
+
+	if !canSplitX && !canSplitY {
+		return carveRoom(blocked, rnd, r)
+	}
+
+	splitX := canSplitX
+	if canSplitX && canSplitY {
+		splitX = rnd.Int(ZERO, ONE).IsZero()
+	}
+
+	var r1, r2 Rectangle
+	if splitX {
+		x := rnd.Int(minPt.X.Plus(minLeafSize), maxPt.X.Minus(minLeafSize).Plus(ONE))
+		r1 = Rectangle{minPt, Pt{x.Minus(ONE), maxPt.Y}}
+		r2 = Rectangle{Pt{x, minPt.Y}, maxPt}
+	} else {
+		y := rnd.Int(minPt.Y.Plus(minLeafSize), maxPt.Y.Minus(minLeafSize).Plus(ONE))
+		r1 = Rectangle{minPt, Pt{maxPt.X, y.Minus(ONE)}}
+		r2 = Rectangle{Pt{minPt.X, y}, maxPt}
+	}
+
+	pt1 := bspSplit(blocked, rnd, r1)
+	pt2 := bspSplit(blocked, rnd, r2)
+	carveCorridor(blocked, pt1, pt2)
+	return pt1
+}
+
+// carveRoom frees a random rectangle inside r, leaving a margin of one
+// position so that rooms in neighboring regions don't merge.
+func carveRoom(blocked *MatBool, rnd *random, r Rectangle) Pt {
+	minPt := r.Min().Plus(Pt{ONE, ONE})
+	maxPt := r.Max().Minus(Pt{ONE, ONE})
+	maxSize := maxPt.Minus(minPt).Plus(Pt{ONE, ONE})
+	minRoomSize := I(bspMinRoomSize)
+	minSize := Pt{Min(minRoomSize, maxSize.X), Min(minRoomSize, maxSize.Y)}
+	if minSize.X.IsNonPositive() || minSize.Y.IsNonPositive() {
+		minPt, maxPt = r.Min(), r.Max()
+		maxSize = maxPt.Minus(minPt).Plus(Pt{ONE, ONE})
+		minSize = maxSize
+	}
+
+	roomSize := Pt{rnd.Int(minSize.X, maxSize.X), rnd.Int(minSize.Y, maxSize.Y)}
+	corner := Pt{
+		rnd.Int(minPt.X, maxPt.X.Minus(roomSize.X).Plus(ONE)),
+		rnd.Int(minPt.Y, maxPt.Y.Minus(roomSize.Y).Plus(ONE))}
+	for y := ZERO; y.Lt(roomSize.Y); y.Inc() {
+		for x := ZERO; x.Lt(roomSize.X); x.Inc() {
+			blocked.Clear(corner.Plus(Pt{x, y}))
+		}
+	}
+	return corner.Plus(roomSize.DivBy(TWO))
+}
+
+// carveCorridor frees an L-shaped path between two positions.
+func carveCorridor(blocked *MatBool, pt1, pt2 Pt) {
+	step := func(from, to Int) Int {
+		if from.Lt(to) {
+			return ONE
+		}
+		return ONE.Negative()
+	}
+	pt := pt1
+	blocked.Clear(pt)
+	for pt.X.Neq(pt2.X) {
+		pt.X.Add(step(pt.X, pt2.X))
+		blocked.Clear(pt)
+	}
+	for pt.Y.Neq(pt2.Y) {
+		pt.Y.Add(step(pt.Y, pt2.Y))
+		blocked.Clear(pt)
+	}
+}
+
+// scatterFurniture blocks count rectangles of pieceSize at random free
+// places in blocked. A piece is only placed if the free area stays connected.
+func scatterFurniture(blocked *MatBool, r *random, count Int, pieceSize Pt) {
+	placed := ZERO
+	maxAttempts := count.Times(I(10))
+	for attempt := ZERO; attempt.Lt(maxAttempts) && placed.Lt(count); attempt.Inc() {
+		corner, err := randomFreePos(*blocked, r)
+		if err != nil {
+			return
+		}
+
+		piece := NewMatBool(blocked.Size())
+		fits := true
+		for y := ZERO; y.Lt(pieceSize.Y) && fits; y.Inc() {
+			for x := ZERO; x.Lt(pieceSize.X) && fits; x.Inc() {
+				pt := corner.Plus(Pt{x, y})
+				fits = blocked.InBounds(pt) && !blocked.At(pt)
+				if fits {
+					piece.Set(pt)
+				}
+			}
+		}
+		if !fits {
+			continue
+		}
+
+		candidate := blocked.Clone()
+		candidate.Add(piece)
+		if _, sizes := freeAreas(candidate); len(sizes) <= 1 {
+			*blocked = candidate
+			placed.Inc()
+		}
+	}
+}
+
+// keepLargestFreeArea blocks all the free positions that are not connected
+// to the largest free area. If several areas have the same size, the first
+// one wins.
+func keepLargestFreeArea(blocked *MatBool) {
+	labels, sizes := freeAreas(*blocked)
+	largest := -1
+	for i := range sizes {
+		if largest < 0 || sizes[i].Gt(sizes[largest]) {
+			largest = i
+		}
+	}
+	for y := ZERO; y.Lt(blocked.Size().Y); y.Inc() {
+		for x := ZERO; x.Lt(blocked.Size().X); x.Inc() {
+			pt := Pt{x, y}
+			if labels.Get(pt) >= 0 && labels.Get(pt) != largest {
+				blocked.Set(pt)
+			}
+		}
+	}
+}
+
+// freeAreas labels the groups of free positions that are connected through
+// their sides. Groups are numbered in the order in which their first
+// position appears, row by row. Blocked positions get the label -1.
+func freeAreas(blocked MatBool) (labels Matrix[int], sizes []Int) {
+	size := blocked.Size()
+	labels = NewMatrix[int](size)
+	for y := ZERO; y.Lt(size.Y); y.Inc() {
+		for x := ZERO; x.Lt(size.X); x.Inc() {
+			labels.Set(Pt{x, y}, -1)
+		}
+	}
+
+	for y := ZERO; y.Lt(size.Y); y.Inc() {
+		for x := ZERO; x.Lt(size.X); x.Inc() {
+			start := Pt{x, y}
+			if blocked.At(start) || labels.Get(start) >= 0 {
+				continue
+			}
+			label := len(sizes)
+			labels.Set(start, label)
+			queue := []Pt{start}
+			for i := 0; i < len(queue); i++ {
+				for _, d := range Directions4() {
+					n := queue[i].Plus(d)
+					if blocked.InBounds(n) && !blocked.At(n) && labels.Get(n) < 0 {
+						labels.Set(n, label)
+						queue = append(queue, n)
+					}
+				}
+			}
+			sizes = append(sizes, I(len(queue)))
+		}
+	}
+	return
+}
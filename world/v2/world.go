@@ -0,0 +1,113 @@
+/*
+Package v2 has the rules of version 2 of the game, so that playthroughs
+recorded with that version can still be replayed exactly. The code is frozen:
+don't change it, even to fix bugs, as that would change how the old
+playthroughs play out. In version 2 the room was only drawn, it didn't block
+the character.
+*/
+package v2
+
+import (
+	"fmt"
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/world"
+	"math"
+)
+
+const Version = 2
+
+func init() {
+	world.RegisterSimulation(Version, func(seed Int) world.Simulation {
+		w := NewWorld(seed)
+		return &w
+	})
+}
+
+// RoomCellSize is the size of the area covered by one position of World.Room.
+var RoomCellSize = U(50)
+
+type Food struct {
+	Pos  Pt
+	Size Pt
+}
+
+type World struct {
+	Seed      Int
+	Size      Pt
+	Character Character
+	Food      Food
+	TimeStep  Int
+	// Room shows which parts of the world are blocked. Each position covers
+	// an area of RoomCellSize x RoomCellSize.
+	Room MatBool
+}
+
+// NewWorld creates a level. The same seed always creates the same level.
+func NewWorld(seed Int) (w World) {
+	w.Seed = seed
+	w.Character = NewCharacter()
+
+	w.Size = UPt(900, 900)
+	sz := 200
+	w.Character.Size = UPt(sz, sz)
+	w.Character.Pos = UPt(100, 200)
+	r := newRandom(seed)
+	w.Room = generateRoom(w.Size.DivBy(RoomCellSize), r)
+	w.Food.Size = UPt(200, 200)
+	w.Food.Pos = w.randomFoodPos(r)
+	return
+}
+
+// RoomCellCenter returns the center of the area covered by a position of
+// w.Room.
+func (w *World) RoomCellCenter(pos Pt) Pt {
+	return pos.Times(RoomCellSize).Plus(Pt{RoomCellSize, RoomCellSize}.DivBy(TWO))
+}
+
+// randomFoodPos returns the center of a random free position of the room,
+// which the character is allowed to move to.
+func (w *World) randomFoodPos(r *random) Pt {
+	candidates := w.Room.Clone()
+	for y := ZERO; y.Lt(candidates.Size().Y); y.Inc() {
+		for x := ZERO; x.Lt(candidates.Size().X); x.Inc() {
+			pos := Pt{x, y}
+			if !w.Character.MoveLimits.ContainsPt(w.RoomCellCenter(pos)) {
+				candidates.Set(pos)
+			}
+		}
+	}
+
+	pos, err := randomFreePos(candidates, r)
+	if err != nil {
+		// No free position is in reach, just put the food in the middle.
+		return w.Size.DivBy(TWO)
+	}
+	return w.RoomCellCenter(pos)
+}
+
+func (w *World) Step(input world.PlayerInput) {
+	if input.Pick {
+		if input.Position.DistTo(w.Character.Pos).Lt(U(150)) {
+			w.Character.Pick()
+		}
+	}
+
+	if input.Release {
+		if w.Character.IsPicked() {
+			w.Character.Release()
+		}
+	}
+
+	w.Character.Step(w, input)
+
+	w.TimeStep.Inc()
+	if w.TimeStep.Eq(I(math.MaxInt64)) {
+		// Damn.
+		Check(fmt.Errorf("got to an unusually large time step: %d", w.TimeStep.ToInt64()))
+	}
+}
+
+// State implements world.Simulation.
+func (w *World) State() any {
+	return w
+}
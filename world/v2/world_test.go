@@ -0,0 +1,31 @@
+package v2
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/world"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWorld_Registered(t *testing.T) {
+	inputs := []world.PlayerInput{
+		{MoveRight: true},
+		{},
+		{MoveDown: true},
+		{Position: UPt(300, 300), Pick: true},
+		{Position: UPt(320, 310)},
+		{Release: true},
+	}
+
+	expected := NewWorld(I(17))
+	for _, input := range inputs {
+		expected.Step(input)
+	}
+
+	var p world.Playthrough
+	p.Version = Version
+	p.Seed = I(17)
+	p.History = inputs
+	p = world.DeserializePlaythrough(p.Serialize())
+	assert.Equal(t, &expected, p.Replay().State())
+}
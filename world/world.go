@@ -1,12 +1,21 @@
 package world
 
 import (
+	"encoding/json"
 	"fmt"
 	. "github.com/marisvali/vlok/gamelib"
+	"github.com/marisvali/vlok/gamelib/gen"
 	"math"
 )
 
-const Version = 1
+const Version = 3
+
+// RoomCellSize is the size of the area covered by one position of World.Room.
+var RoomCellSize = U(50)
+
+// maxRoomAttempts is how many rooms NewWorld generates before giving up on
+// finding one with enough space for the character and the food.
+const maxRoomAttempts = 100
 
 type Food struct {
 	Pos  Pt
@@ -14,10 +23,16 @@ type Food struct {
 }
 
 type World struct {
+	Seed      Int
 	Size      Pt
 	Character Character
 	Food      Food
 	TimeStep  Int
+	// Room shows which parts of the world are blocked. Each position covers
+	// an area of RoomCellSize x RoomCellSize. Change it with SetRoom.
+	Room MatBool
+	// obstacles is computed from Room by SetRoom, see Obstacles.
+	obstacles []Obstacle
 }
 
 type PlayerInput struct {
@@ -31,18 +46,146 @@ type PlayerInput struct {
 	MoveToFood bool
 }
 
-func NewWorld() (w World) {
+// NewWorld creates a level. The same seed always creates the same level.
+func NewWorld(seed Int) (w World) {
+	w.Seed = seed
 	w.Character = NewCharacter()
 
 	w.Size = UPt(900, 900)
 	sz := 200
 	w.Character.Size = UPt(sz, sz)
-	w.Character.Pos = UPt(100, 200)
+	r := NewRand(seed)
 	w.Food.Size = UPt(200, 200)
-	w.Food.Pos = UPt(450, 450)
+
+	// Put the character and the food on different free positions of the same
+	// walkable area, so that the character doesn't start inside a wall and
+	// can always get to the food. Rooms without enough space are generated
+	// again.
+	var walkable MatBool
+	for attempt := 0; ; attempt++ {
+		if attempt == maxRoomAttempts {
+			Check(fmt.Errorf("failed to generate a room with a walkable "+
+				"area in %d attempts", maxRoomAttempts))
+			break
+		}
+		w.SetRoom(gen.Room(w.Size.DivBy(RoomCellSize), r))
+		walkable = w.largestWalkableArea()
+		if walkable.Count().Geq(TWO) {
+			break
+		}
+	}
+	unreachable := walkable.Clone()
+	unreachable.Negate()
+	var err error
+	w.Character.Pos, err = w.randomCellCenter(&unreachable, r)
+	Check(err)
+	w.Food.Pos, err = w.randomCellCenter(&unreachable, r)
+	Check(err)
+
+	// Never ship a level with food that can't be reached.
+	foodCells := NewMatBool(w.Room.Size())
+	foodCells.Set(w.Food.Pos.DivBy(RoomCellSize))
+	characterCell := w.Character.Pos.DivBy(RoomCellSize)
+	if !walkable.AllConnected(characterCell, foodCells, Directions4()) {
+		Check(fmt.Errorf("food at %v can't be reached from %v for seed %d",
+			w.Food.Pos, w.Character.Pos, seed.ToInt64()))
+	}
+	return
+}
+
+// RoomCellCenter returns the center of the area covered by a position of
+// w.Room.
+func (w *World) RoomCellCenter(pos Pt) Pt {
+	return pos.Times(RoomCellSize).Plus(Pt{RoomCellSize, RoomCellSize}.DivBy(TWO))
+}
+
+// IsBlocked returns true if pt is on a blocked position of w.Room or outside
+// of it.
+func (w *World) IsBlocked(pt Pt) bool {
+	if pt.X.IsNegative() || pt.Y.IsNegative() {
+		return true
+	}
+	pos := pt.DivBy(RoomCellSize)
+	return !w.Room.InBounds(pos) || w.Room.At(pos)
+}
+
+// SetRoom changes the room and computes the obstacles that the character
+// collides with, so that they aren't built again for every move.
+func (w *World) SetRoom(room MatBool) {
+	w.Room = room
+	w.obstacles = nil
+	halfSize := w.Character.CollisionSize.DivBy(TWO)
+	size := Pt{RoomCellSize, RoomCellSize}
+	w.Room.ForEach(func(pos Pt) {
+		corner := pos.Times(RoomCellSize)
+		cell := Rectangle{corner, corner.Plus(size).Minus(Pt{ONE, ONE})}
+		w.obstacles = append(w.obstacles, cell.Inflated(halfSize))
+	})
+}
+
+// Obstacles returns the blocked positions of the room, grown by half of the
+// character's collision box. Sweeping the center of the character against
+// them is the same as sweeping its collision box against the room.
+func (w *World) Obstacles() []Obstacle {
+	return w.obstacles
+}
+
+// UnmarshalJSON reads a World saved with json.Marshal and computes its
+// obstacles, which are not saved.
+func (w *World) UnmarshalJSON(data []byte) error {
+	type world World
+	if err := json.Unmarshal(data, (*world)(w)); err != nil {
+		return err
+	}
+	w.SetRoom(w.Room)
+	return nil
+}
+
+// unreachableCells returns the positions of w.Room that the character can't
+// be on, either because they are blocked or because they are outside of
+// its move limits.
+func (w *World) unreachableCells() (unreachable MatBool) {
+	unreachable = w.Room.Clone()
+	for y := ZERO; y.Lt(unreachable.Size().Y); y.Inc() {
+		for x := ZERO; x.Lt(unreachable.Size().X); x.Inc() {
+			pos := Pt{x, y}
+			if !w.Character.MoveLimits.ContainsPt(w.RoomCellCenter(pos)) {
+				unreachable.Set(pos)
+			}
+		}
+	}
+	return
+}
+
+// largestWalkableArea returns the largest group of connected positions of
+// w.Room that the character can be on. Positions are connected only through
+// their sides, as the character's collision box doesn't fit between two
+// blocked positions that touch at a corner.
+func (w *World) largestWalkableArea() (area MatBool) {
+	free := w.unreachableCells()
+	free.Negate()
+	labels, regions := free.LabelRegions(Directions4())
+	largest := LargestRegion(regions)
+	area = NewMatBool(free.Size())
+	free.ForEach(func(pt Pt) {
+		if labels.Get(pt) == largest {
+			area.Set(pt)
+		}
+	})
 	return
 }
 
+// randomCellCenter returns the center of a random position that is false in
+// unreachable and then sets that position, so that it isn't picked again.
+func (w *World) randomCellCenter(unreachable *MatBool, r *Rand) (Pt, error) {
+	pos, err := unreachable.TryRandomUnoccupiedPosWith(r)
+	if err != nil {
+		return Pt{}, err
+	}
+	unreachable.Set(pos)
+	return w.RoomCellCenter(pos), nil
+}
+
 func (w *World) Step(input PlayerInput) {
 	if input.Pick {
 		if input.Position.DistTo(w.Character.Pos).Lt(U(150)) {
@@ -0,0 +1,97 @@
+package world
+
+import (
+	. "github.com/marisvali/vlok/gamelib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWorld_RoomBlocksCharacter(t *testing.T) {
+	RSeed(I(4))
+	for seed := 0; seed < 20; seed++ {
+		w := NewWorld(I(seed))
+		assert.False(t, w.IsBlocked(w.Character.Pos))
+		assert.False(t, w.IsBlocked(w.Food.Pos))
+		for i := 0; i < 300; i++ {
+			var input PlayerInput
+			input.Position = Pt{RInt(ZERO, w.Size.X), RInt(ZERO, w.Size.Y)}
+			input.Pick = RInt(I(0), I(9)).IsZero()
+			input.Release = RInt(I(0), I(9)).IsZero()
+			input.MoveLeft = RInt(I(0), I(9)).IsZero()
+			input.MoveRight = RInt(I(0), I(9)).IsZero()
+			input.MoveUp = RInt(I(0), I(9)).IsZero()
+			input.MoveDown = RInt(I(0), I(9)).IsZero()
+			input.MoveToFood = RInt(I(0), I(9)).IsZero()
+			w.Step(input)
+			assert.True(t, w.Character.MoveLimits.ContainsPt(w.Character.Pos))
+			half := w.Character.CollisionSize.DivBy(TWO)
+			box := Rectangle{w.Character.Pos.Minus(half), w.Character.Pos.Plus(half)}
+			w.Room.ForEach(func(pos Pt) {
+				corner := pos.Times(RoomCellSize)
+				size := Pt{RoomCellSize, RoomCellSize}
+				cell := Rectangle{corner, corner.Plus(size).Minus(Pt{ONE, ONE})}
+				intersects, _ := cell.Intersect(box)
+				assert.False(t, intersects)
+			})
+		}
+	}
+}
+
+func TestNewWorld_FoodIsReachable(t *testing.T) {
+	for seed := 0; seed < 500; seed++ {
+		w := NewWorld(I(seed))
+		free := w.unreachableCells()
+		free.Negate()
+		labels, _ := free.LabelRegions(Directions4())
+		characterCell := w.Character.Pos.DivBy(RoomCellSize)
+		foodCell := w.Food.Pos.DivBy(RoomCellSize)
+		assert.NotEqual(t, characterCell, foodCell, "seed %d", seed)
+		assert.True(t, free.At(characterCell), "seed %d", seed)
+		assert.Equal(t, labels.Get(characterCell), labels.Get(foodCell),
+			"seed %d", seed)
+	}
+}
+
+func TestCharacter_Slides(t *testing.T) {
+	w := NewWorld(ZERO)
+	room := NewMatBool(w.Room.Size())
+	// Covers the area from (400, 400) to (450, 450).
+	room.Set(IPt(8, 8))
+	w.SetRoom(room)
+
+	// Go diagonally into the left side of the blocked position and slide
+	// down along it.
+	c := &w.Character
+	c.Pos = UPt(350, 425)
+	c.ChangePos(&w, UPt(410, 445))
+	assert.True(t, c.Pos.X.Lt(U(380)))
+	assert.True(t, c.Pos.X.Gt(U(379)))
+	assert.True(t, c.Pos.Y.Gt(U(425)))
+
+	// Stop at the edge of the move limits and slide along it.
+	c.Pos = UPt(780, 500)
+	c.ChangePos(&w, UPt(900, 520))
+	assert.Equal(t, UPt(790, 520), c.Pos)
+}
+
+func TestCharacter_AngledMoveLimits(t *testing.T) {
+	w := NewWorld(ZERO)
+	w.SetRoom(NewMatBool(w.Room.Size()))
+	c := &w.Character
+	c.MoveLimits = NewPolygon(UPt(100, 100), UPt(800, 100), UPt(100, 800))
+
+	// Walk into the angled wall and slide along it.
+	c.Pos = UPt(400, 300)
+	for i := 0; i < 100; i++ {
+		c.MoveRight(&w)
+		assert.True(t, c.MoveLimits.ContainsPt(c.Pos))
+	}
+	assert.True(t, c.Pos.X.Gt(U(600)))
+	assert.True(t, c.Pos.Y.Lt(U(300)))
+
+	// Dragging outside of the limits stops at the angled wall.
+	c.Pick()
+	w.Step(PlayerInput{Position: UPt(800, 800)})
+	assert.True(t, c.MoveLimits.ContainsPt(c.Pos))
+	assert.True(t, c.Pos.X.Plus(c.Pos.Y).Gt(U(890)))
+}